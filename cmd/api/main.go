@@ -6,13 +6,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
+	"time"
 
 	"example.com/goapi/docs"
 	"example.com/goapi/internal/config"
 	"example.com/goapi/internal/config/env"
 	"example.com/goapi/internal/database"
 	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/feed"
+	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/role"
+	"example.com/goapi/internal/jwtkeys"
+	"example.com/goapi/internal/repository"
 	"example.com/goapi/internal/router"
 	"example.com/goapi/internal/utils/logger"
 	"example.com/goapi/internal/utils/validator"
@@ -23,15 +32,39 @@ const version = "0.0.1"
 var appEnv = env.GetString("APP_ENV", "dev")
 var isProd = appEnv == "prod"
 
+// Time in-flight requests get to finish once the server is asked to stop
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	logger.Setup(isProd)
 
+	// Cancelled on SIGINT or SIGTERM, it stops the background jobs and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	c := config.New()
+	if err := c.Validate(isProd); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+
 	v := validator.New()
 	db, _ := database.NewDB(c)
 	rd := cache.NewClient(c)
 
-	r := router.NewRouter(db, v, rd)
+	ks, err := jwtkeys.New(c.JWT)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
+	// Grants the admin role to the users of AUTH_ADMIN_EMAILS, e.g. the first admin
+	if err := role.GrantAdmins(ctx, repository.NewRoleRepository(db), c.Auth.AdminEmails); err != nil {
+		log.Fatalf("Failed to grant admin roles: %v", err)
+	}
+
+	// Pushes new posts into the timelines of followers
+	fanOut := feed.NewFanOut(repository.NewFeedRepository(db), rd, c.Feed)
+
+	r := router.NewRouter(c, db, v, rd, ks, fanOut)
 	s := &http.Server{
 		Addr:         fmt.Sprintf(":%d", c.Server.Port),
 		Handler:      r,
@@ -43,7 +76,7 @@ func main() {
 	swaggerInit()
 
 	log.Println("Connecting to redis client at " + rd.Options().Addr)
-	err := rd.Ping(context.Background())
+	err = rd.Ping(context.Background())
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
@@ -57,9 +90,31 @@ func main() {
 		return runtime.NumGoroutine()
 	}))
 
-	log.Println("Starting server at port" + s.Addr)
-	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Server startup failed!!")
+	// Publishes scheduled posts when they are due
+	post.NewScheduler(repository.NewRepository(db), fanOut, rd, c.Post.SchedulerInterval).Start(ctx)
+	// Removes posts from the trash once their retention ended
+	post.NewTrashPurger(repository.NewRepository(db), c.Post.TrashRetention(), c.Post.TrashPurgeInterval).Start(ctx)
+
+	go func() {
+		log.Println("Starting server at port" + s.Addr)
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server startup failed!!")
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+
+	// After the server, so posts published by the last requests are pushed as well
+	if err := fanOut.Stop(shutdownCtx); err != nil {
+		log.Printf("Fan-out shutdown failed: %v", err)
 	}
 }
 
@@ -16,6 +16,7 @@ const (
 	ErrDBTxRollbackFailure = "DB_TX_ROLLBACK_FAILURE"
 	ErrDBNoRows            = "DB_NO_ROWS"
 	ErrDBDuplicateEntry    = "DB_DUPLICATE_ENTRY"
+	ErrDBVersionConflict   = "DB_VERSION_CONFLICT"
 
 	// Validation errors
 	ErrValidationFailed     = "VALIDATION_FAILED"
@@ -26,6 +27,8 @@ const (
 	ErrTokenGeneration      = "FAILED_TOKEN_GENERATION"
 	ErrInvalidCredentials   = "invalid credentials"
 	ErrUnauthorized         = "UNAUTHORIZED_TOKEN"
+	ErrFileTooLarge         = "FILE_TOO_LARGE"
+	ErrUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
 
 	// Authentication & Authorization
 	ErrAuthTokenMissing  = "AUTH_TOKEN_MISSING"
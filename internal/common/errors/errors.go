@@ -7,11 +7,16 @@ var (
 	InternalServerError   = "internal server error"
 	PasswordHashingFailed = "password hashing failed"
 
+	// Auth errors
+	InvalidCredentials = "invalid email or password"
+	TooManyAttempts    = "too many failed login attempts, try again later"
+
 	// DB errors
 	DBDataInsertFailure = "db data insert failure"
 	DBDataAccessFailure = "db data access failure"
 	DBDataUpdateFailure = "db data update failure"
 	DBDataRemoveFailure = "db data remove failure"
+	DBVersionConflict   = "resource was modified by another request"
 
 	// Json errors
 	JSONEncodeFailure = "json encode failure"
@@ -26,10 +26,15 @@ func ApplyPagination(query *gorm.DB, params *QueryParams) *gorm.DB {
 
 // ApplySorting applies sorting (ascending or descending) to the query.
 func ApplySorting(query *gorm.DB, params *QueryParams) *gorm.DB {
+	return ApplySortingBy(query, params, "created_at")
+}
+
+// ApplySortingBy is ApplySorting with a different time column or expression to order by.
+func ApplySortingBy(query *gorm.DB, params *QueryParams, column string) *gorm.DB {
 	if params.Sort == "desc" {
-		query = query.Order("created_at desc")
+		query = query.Order(column + " desc")
 	} else {
-		query = query.Order("created_at asc")
+		query = query.Order(column + " asc")
 	}
 	return query
 }
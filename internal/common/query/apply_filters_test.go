@@ -0,0 +1,42 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApplySortingBy(t *testing.T) {
+	const column = "COALESCE(publish_at, created_at)"
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"asc", "ORDER BY COALESCE(publish_at, created_at) asc"},
+		{"desc", "ORDER BY COALESCE(publish_at, created_at) desc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			db := dryRun(t)
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return ApplySortingBy(tx.Table("posts"), &QueryParams{Sort: tt.sort}, column).Find(&[]map[string]any{})
+			})
+			if !strings.Contains(sql, tt.want) {
+				t.Errorf("sql = %q, want it to contain %q", sql, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joeshaw/envdecode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Secrets shorter than this are considered weak in prod
+const minSecretLength = 32
+
+// Well known placeholder secrets that must never reach prod
+var defaultSecrets = []string{"secret", "refresh", "changeme", "XYZABC123"}
+
+type ConfAuth struct {
+	SecretKey  string        `env:"AUTH_SECRET_KEY,required"`
+	AccessTTL  time.Duration `env:"AUTH_ACCESS_TTL,default=15m"`
+	RefreshTTL time.Duration `env:"AUTH_REFRESH_TTL,default=168h"`
+	BcryptCost int           `env:"AUTH_BCRYPT_COST,default=10"`
+	Issuer     string        `env:"AUTH_ISSUER,default=goapi"`
+	Audience   string        `env:"AUTH_AUDIENCE,default=goapi"`
+
+	// Login throttling: after MaxAttempts failures within AttemptWindow the account
+	// (or IP) is locked for LockoutBase, doubling with every further failure up to LockoutMax.
+	LoginMaxAttempts   int           `env:"AUTH_LOGIN_MAX_ATTEMPTS,default=5"`
+	LoginMaxAttemptsIP int           `env:"AUTH_LOGIN_MAX_ATTEMPTS_IP,default=20"`
+	LoginAttemptWindow time.Duration `env:"AUTH_LOGIN_ATTEMPT_WINDOW,default=15m"`
+	LoginLockoutBase   time.Duration `env:"AUTH_LOGIN_LOCKOUT_BASE,default=1m"`
+	LoginLockoutMax    time.Duration `env:"AUTH_LOGIN_LOCKOUT_MAX,default=1h"`
+
+	// Cookies of browser clients (access, refresh and CSRF token)
+	CookieDomain   string `env:"AUTH_COOKIE_DOMAIN"`
+	CookiePath     string `env:"AUTH_COOKIE_PATH,default=/"`
+	CookieSecure   bool   `env:"AUTH_COOKIE_SECURE,default=true"`
+	CookieSameSite string `env:"AUTH_COOKIE_SAMESITE,default=strict"`
+
+	// Users granted the admin role at startup, e.g. to create the first admin
+	AdminEmails []string `env:"AUTH_ADMIN_EMAILS"`
+}
+
+// SameSite returns the SameSite mode of auth cookies
+func (c *ConfAuth) SameSite() http.SameSite {
+	switch strings.ToLower(c.CookieSameSite) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+func NewConfAuth() *ConfAuth {
+	var cfg ConfAuth
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load auth config: " + err.Error())
+	}
+
+	return &cfg
+}
+
+// Validate checks the auth settings. Weak secrets are only rejected in prod.
+func (c *ConfAuth) Validate(isProd bool) error {
+	if c.AccessTTL <= 0 || c.RefreshTTL <= 0 {
+		return fmt.Errorf("AUTH_ACCESS_TTL and AUTH_REFRESH_TTL must be positive")
+	}
+
+	if c.LoginMaxAttempts <= 0 || c.LoginMaxAttemptsIP <= 0 {
+		return fmt.Errorf("AUTH_LOGIN_MAX_ATTEMPTS and AUTH_LOGIN_MAX_ATTEMPTS_IP must be positive")
+	}
+
+	if c.LoginAttemptWindow <= 0 || c.LoginLockoutBase <= 0 || c.LoginLockoutMax < c.LoginLockoutBase {
+		return fmt.Errorf("AUTH_LOGIN_ATTEMPT_WINDOW and AUTH_LOGIN_LOCKOUT_BASE must be positive and AUTH_LOGIN_LOCKOUT_MAX at least AUTH_LOGIN_LOCKOUT_BASE")
+	}
+
+	if !slices.Contains([]string{"strict", "lax", "none"}, strings.ToLower(c.CookieSameSite)) {
+		return fmt.Errorf("AUTH_COOKIE_SAMESITE must be strict, lax or none")
+	}
+
+	// Browsers reject SameSite=None cookies without Secure
+	if c.SameSite() == http.SameSiteNoneMode && !c.CookieSecure {
+		return fmt.Errorf("AUTH_COOKIE_SAMESITE=none requires AUTH_COOKIE_SECURE")
+	}
+
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("AUTH_BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if !isProd {
+		return nil
+	}
+
+	if err := validateSecret("AUTH_SECRET_KEY", c.SecretKey); err != nil {
+		return err
+	}
+
+	if c.BcryptCost < bcrypt.DefaultCost {
+		return fmt.Errorf("AUTH_BCRYPT_COST must be at least %d in prod", bcrypt.DefaultCost)
+	}
+
+	if !c.CookieSecure {
+		return fmt.Errorf("AUTH_COOKIE_SECURE must be enabled in prod")
+	}
+
+	return nil
+}
+
+func validateSecret(name, secret string) error {
+	if slices.Contains(defaultSecrets, secret) {
+		return fmt.Errorf("%s still uses a default value", name)
+	}
+
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("%s must be at least %d characters long", name, minSecretLength)
+	}
+
+	return nil
+}
@@ -4,6 +4,13 @@ type Conf struct {
 	Server *ConfServer
 	DB     *ConfDB
 	Redis  *ConfRedis
+	Mail   *ConfMail
+	JWT    *ConfJWT
+	Auth   *ConfAuth
+	OIDC   *ConfOIDC
+	Feed   *ConfFeed
+	Post   *ConfPost
+	Media  *ConfMedia
 }
 
 func New() *Conf {
@@ -11,5 +18,41 @@ func New() *Conf {
 		Server: NewConfServer(),
 		DB:     NewConfDB(),
 		Redis:  NewConfRedis(),
+		Mail:   NewConfMail(),
+		JWT:    NewConfJWT(),
+		Auth:   NewConfAuth(),
+		OIDC:   NewConfOIDC(),
+		Feed:   NewConfFeed(),
+		Post:   NewConfPost(),
+		Media:  NewConfMedia(),
 	}
 }
+
+// Validate checks settings that can't be expressed with env tags alone
+func (c *Conf) Validate(isProd bool) error {
+	if err := c.Server.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.Validate(isProd); err != nil {
+		return err
+	}
+
+	if err := c.JWT.Validate(isProd); err != nil {
+		return err
+	}
+
+	if err := c.OIDC.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Feed.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Post.Validate(); err != nil {
+		return err
+	}
+
+	return c.Media.Validate()
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joeshaw/envdecode"
+)
+
+// ConfFeed configures the timelines cached in Redis
+type ConfFeed struct {
+	// Number of post IDs kept per timeline, older pages are read from SQL
+	TimelineSize int           `env:"FEED_TIMELINE_SIZE,default=800"`
+	TimelineTTL  time.Duration `env:"FEED_TIMELINE_TTL,default=24h"`
+	// Background workers pushing new posts into the timelines of followers
+	FanOutWorkers   int `env:"FEED_FANOUT_WORKERS,default=4"`
+	FanOutQueueSize int `env:"FEED_FANOUT_QUEUE_SIZE,default=1024"`
+	// Authors with at least this many followers are merged into timelines on read instead
+	FanOutMaxFollowers int64 `env:"FEED_FANOUT_MAX_FOLLOWERS,default=10000"`
+}
+
+func NewConfFeed() *ConfFeed {
+	var cfg ConfFeed
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load feed config: " + err.Error())
+	}
+
+	return &cfg
+}
+
+// Validate checks the sizes are usable
+func (c *ConfFeed) Validate() error {
+	if c.TimelineSize <= 0 || c.TimelineTTL <= 0 {
+		return fmt.Errorf("FEED_TIMELINE_SIZE and FEED_TIMELINE_TTL must be positive")
+	}
+
+	if c.FanOutWorkers <= 0 || c.FanOutQueueSize <= 0 || c.FanOutMaxFollowers <= 0 {
+		return fmt.Errorf("FEED_FANOUT_WORKERS, FEED_FANOUT_QUEUE_SIZE and FEED_FANOUT_MAX_FOLLOWERS must be positive")
+	}
+
+	return nil
+}
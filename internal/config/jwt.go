@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/joeshaw/envdecode"
+)
+
+// ConfJWT configures the keys used to sign and verify access tokens.
+// NOTE: Keys listed in JWT_VERIFY_KEY_FILES are only used for verification, which lets
+// tokens signed with a previous key stay valid while rotating to a new one.
+type ConfJWT struct {
+	Algorithm      string   `env:"JWT_ALGORITHM,default=EdDSA"`
+	KeyID          string   `env:"JWT_KEY_ID"`
+	PrivateKey     string   `env:"JWT_PRIVATE_KEY"`
+	PrivateKeyFile string   `env:"JWT_PRIVATE_KEY_FILE"`
+	VerifyKeyFiles []string `env:"JWT_VERIFY_KEY_FILES"`
+	HMACSecret     string   `env:"JWT_HMAC_SECRET"`
+}
+
+func NewConfJWT() *ConfJWT {
+	var cfg ConfJWT
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load JWT config: " + err.Error())
+	}
+
+	return &cfg
+}
+
+// Validate checks the JWT settings. In prod a signing key must be configured,
+// a generated key would differ between instances and restarts.
+func (c *ConfJWT) Validate(isProd bool) error {
+	if c.Algorithm == "HS256" {
+		if c.HMACSecret == "" {
+			return fmt.Errorf("JWT_HMAC_SECRET is required for HS256")
+		}
+
+		if isProd {
+			return validateSecret("JWT_HMAC_SECRET", c.HMACSecret)
+		}
+		return nil
+	}
+
+	if isProd && c.PrivateKey == "" && c.PrivateKeyFile == "" {
+		return fmt.Errorf("JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE is required in prod")
+	}
+
+	return nil
+}
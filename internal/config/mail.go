@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/joeshaw/envdecode"
+)
+
+type ConfMail struct {
+	Driver   string `env:"MAIL_DRIVER,default=log"`
+	From     string `env:"MAIL_FROM,default=no-reply@example.com"`
+	FileDir  string `env:"MAIL_FILE_DIR,default=tmp/mails"`
+	SMTPHost string `env:"MAIL_SMTP_HOST"`
+	SMTPPort int    `env:"MAIL_SMTP_PORT,default=587"`
+	SMTPUser string `env:"MAIL_SMTP_USER"`
+	SMTPPass string `env:"MAIL_SMTP_PASS"`
+	AppURL   string `env:"MAIL_APP_URL,default=http://localhost:8080"`
+}
+
+func NewConfMail() *ConfMail {
+	var cfg ConfMail
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load mail config: " + err.Error())
+	}
+
+	return &cfg
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joeshaw/envdecode"
+)
+
+// ConfMedia configures uploads and the blob store they are kept in
+type ConfMedia struct {
+	// Blob store, "local" or "s3"
+	Driver string `env:"MEDIA_DRIVER,default=local"`
+	// Largest accepted upload in bytes
+	MaxSize int64 `env:"MEDIA_MAX_SIZE,default=10485760"`
+	// MIME types accepted as uploads, separated by ";"
+	AllowedTypes []string `env:"MEDIA_ALLOWED_TYPES,default=image/jpeg;image/png;image/gif;image/webp"`
+	// Base URL of the stored files, derived from the driver if empty
+	PublicURL string `env:"MEDIA_PUBLIC_URL"`
+	LocalDir  string `env:"MEDIA_LOCAL_DIR,default=tmp/uploads"`
+
+	// Any S3 compatible store, e.g. MinIO for local development
+	S3Endpoint  string `env:"MEDIA_S3_ENDPOINT"`
+	S3Region    string `env:"MEDIA_S3_REGION,default=us-east-1"`
+	S3Bucket    string `env:"MEDIA_S3_BUCKET"`
+	S3AccessKey string `env:"MEDIA_S3_ACCESS_KEY"`
+	S3SecretKey string `env:"MEDIA_S3_SECRET_KEY"`
+}
+
+func NewConfMedia() *ConfMedia {
+	var cfg ConfMedia
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load media config: " + err.Error())
+	}
+
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	cfg.S3Endpoint = strings.TrimSuffix(cfg.S3Endpoint, "/")
+	return &cfg
+}
+
+// Validate checks the configured driver has everything it needs
+func (c *ConfMedia) Validate() error {
+	if c.MaxSize <= 0 {
+		return fmt.Errorf("MEDIA_MAX_SIZE must be positive")
+	}
+
+	if len(c.AllowedTypes) == 0 {
+		return fmt.Errorf("MEDIA_ALLOWED_TYPES must not be empty")
+	}
+
+	switch c.Driver {
+	case "local":
+		if c.LocalDir == "" {
+			return fmt.Errorf("MEDIA_LOCAL_DIR is required for the local driver")
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+			return fmt.Errorf("MEDIA_S3_ENDPOINT, MEDIA_S3_BUCKET, MEDIA_S3_ACCESS_KEY and MEDIA_S3_SECRET_KEY are required for the s3 driver")
+		}
+	default:
+		return fmt.Errorf("MEDIA_DRIVER: unknown driver '%s'", c.Driver)
+	}
+
+	return nil
+}
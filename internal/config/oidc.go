@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"example.com/goapi/internal/config/env"
+	"github.com/joeshaw/envdecode"
+)
+
+// ConfOIDC configures sign in with external OpenID Connect providers.
+// Providers lists their names, the settings of each one are read from
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and OIDC_<NAME>_SCOPES.
+type ConfOIDC struct {
+	Providers   []string `env:"OIDC_PROVIDERS"`
+	CallbackURL string   `env:"OIDC_CALLBACK_URL,default=http://localhost:8080/api/v1/auth/oidc"`
+
+	// Filled from the OIDC_<NAME>_* variables of each provider
+	Provider map[string]*ConfOIDCProvider
+}
+
+type ConfOIDCProvider struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+func NewConfOIDC() *ConfOIDC {
+	var cfg ConfOIDC
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load OIDC config: " + err.Error())
+	}
+
+	cfg.Provider = make(map[string]*ConfOIDCProvider, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg.Provider[name] = &ConfOIDCProvider{
+			Name:         name,
+			IssuerURL:    env.GetString(prefix+"ISSUER", ""),
+			ClientID:     env.GetString(prefix+"CLIENT_ID", ""),
+			ClientSecret: env.GetString(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(env.GetString(prefix+"SCOPES", "openid email profile")),
+		}
+	}
+
+	return &cfg
+}
+
+// RedirectURL returns the callback URL registered at the provider
+func (c *ConfOIDC) RedirectURL(provider string) string {
+	return strings.TrimRight(c.CallbackURL, "/") + "/" + provider + "/callback"
+}
+
+// Validate checks every configured provider is complete
+func (c *ConfOIDC) Validate() error {
+	for name, p := range c.Provider {
+		if p.IssuerURL == "" || p.ClientID == "" {
+			return fmt.Errorf("OIDC provider %q needs an issuer and a client id", name)
+		}
+	}
+
+	return nil
+}
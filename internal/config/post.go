@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/joeshaw/envdecode"
+)
+
+// ConfPost configures the engagement features of posts
+type ConfPost struct {
+	// Reactions users can leave on a post, separated by ";"
+	ReactionKinds []string `env:"POST_REACTION_KINDS,default=like;love;laugh;wow;sad;angry"`
+	// How often scheduled posts are checked for publication
+	SchedulerInterval time.Duration `env:"POST_SCHEDULER_INTERVAL,default=30s"`
+	// Deleted posts stay in the trash for this many days before they are purged
+	TrashRetentionDays int           `env:"POST_TRASH_RETENTION_DAYS,default=30"`
+	TrashPurgeInterval time.Duration `env:"POST_TRASH_PURGE_INTERVAL,default=1h"`
+}
+
+var reactionKindPattern = regexp.MustCompile(`^[a-z_]{1,32}$`)
+
+func NewConfPost() *ConfPost {
+	var cfg ConfPost
+	if err := envdecode.StrictDecode(&cfg); err != nil {
+		panic("Failed to load post config: " + err.Error())
+	}
+
+	return &cfg
+}
+
+// TrashRetention returns how long deleted posts can be restored
+func (c *ConfPost) TrashRetention() time.Duration {
+	return time.Duration(c.TrashRetentionDays) * 24 * time.Hour
+}
+
+// Validate checks the reaction kinds can be stored and the background jobs can run
+func (c *ConfPost) Validate() error {
+	if c.SchedulerInterval <= 0 {
+		return fmt.Errorf("POST_SCHEDULER_INTERVAL must be positive")
+	}
+
+	if c.TrashRetentionDays < 1 {
+		return fmt.Errorf("POST_TRASH_RETENTION_DAYS must be at least 1")
+	}
+
+	if c.TrashPurgeInterval <= 0 {
+		return fmt.Errorf("POST_TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	if len(c.ReactionKinds) == 0 {
+		return fmt.Errorf("POST_REACTION_KINDS must not be empty")
+	}
+
+	for i, kind := range c.ReactionKinds {
+		if !reactionKindPattern.MatchString(kind) {
+			return fmt.Errorf("POST_REACTION_KINDS: invalid kind '%s'", kind)
+		}
+
+		if slices.Contains(c.ReactionKinds[:i], kind) {
+			return fmt.Errorf("POST_REACTION_KINDS: duplicate kind '%s'", kind)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/joeshaw/envdecode"
@@ -12,6 +15,11 @@ type ConfServer struct {
 	TimeoutWrite time.Duration `env:"SERVER_TIMEOUT_WRITE,required"`
 	TimeoutIdle  time.Duration `env:"SERVER_TIMEOUT_IDLE,required"`
 	Debug        bool          `env:"SERVER_DEBUG,required"`
+	// Origins allowed to call the API with credentials, separated by ";"
+	CORSAllowedOrigins []string `env:"SERVER_CORS_ALLOWED_ORIGINS,default=http://localhost:3000"`
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers are trusted, separated by ";". Other clients could spoof them.
+	TrustedProxies []string `env:"SERVER_TRUSTED_PROXIES"`
 }
 
 func NewConfServer() *ConfServer {
@@ -21,3 +29,37 @@ func NewConfServer() *ConfServer {
 	}
 	return &cfg
 }
+
+// Validate checks the trusted proxies are addresses or CIDR ranges
+func (c *ConfServer) Validate() error {
+	_, err := c.TrustedProxyPrefixes()
+	return err
+}
+
+// TrustedProxyPrefixes parses the trusted proxies, single addresses become a prefix of their own
+func (c *ConfServer) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("SERVER_TRUSTED_PROXIES: invalid address '%s'", proxy)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("SERVER_TRUSTED_PROXIES: invalid range '%s'", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	loginFailuresPrefix = "auth:login:failures:"
+	loginLockPrefix     = "auth:login:lock:"
+)
+
+// LoginAttemptStore counts failed logins and keeps temporary lockouts.
+// Keys identify what is limited, e.g. an account or a client IP.
+type LoginAttemptStore interface {
+	// LockedFor returns how long key is still locked out, zero if it isn't
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// RegisterFailure counts a failed attempt and returns the failures within ttl
+	RegisterFailure(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// Reset clears the failures of key, a running lockout is kept
+	Reset(ctx context.Context, key string) error
+}
+
+var (
+	_ LoginAttemptStore = (*Client)(nil)
+	_ LoginAttemptStore = (*MemoryLoginAttemptStore)(nil)
+)
+
+// LockedFor implements LoginAttemptStore.
+func (c *Client) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.PTTL(ctx, loginLockPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// Negative values mean the key doesn't exist or has no expiry
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// RegisterFailure implements LoginAttemptStore.
+func (c *Client) RegisterFailure(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := c.TxPipeline()
+	incr := pipe.Incr(ctx, loginFailuresPrefix+key)
+	pipe.Expire(ctx, loginFailuresPrefix+key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Lock implements LoginAttemptStore.
+func (c *Client) Lock(ctx context.Context, key string, d time.Duration) error {
+	return c.Set(ctx, loginLockPrefix+key, 1, d).Err()
+}
+
+// Reset implements LoginAttemptStore.
+func (c *Client) Reset(ctx context.Context, key string) error {
+	return c.Del(ctx, loginFailuresPrefix+key).Err()
+}
+
+// MemoryLoginAttemptStore is an in-process LoginAttemptStore for tests and single instance setups.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	failures map[string]memoryCounter
+	locks    map[string]time.Time
+}
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		now:      time.Now,
+		failures: make(map[string]memoryCounter),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+// LockedFor implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+
+	left := until.Sub(s.now())
+	if left <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+
+	return left, nil
+}
+
+// RegisterFailure implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) RegisterFailure(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	counter := s.failures[key]
+	if !now.Before(counter.expiresAt) {
+		counter.count = 0
+	}
+
+	counter.count++
+	counter.expiresAt = now.Add(ttl)
+	s.failures[key] = counter
+
+	return counter.count, nil
+}
+
+// Lock implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) Lock(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = s.now().Add(d)
+	return nil
+}
+
+// Reset implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
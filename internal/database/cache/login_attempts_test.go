@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewMemoryLoginAttemptStore()
+	s.now = func() time.Time { return now }
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := s.RegisterFailure(ctx, "alice", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Fatalf("failures = %d, want %d", count, want)
+		}
+	}
+
+	// The window is counted from the last failure
+	now = now.Add(time.Minute)
+	if count, _ := s.RegisterFailure(ctx, "alice", time.Minute); count != 1 {
+		t.Fatalf("failures after the window = %d, want 1", count)
+	}
+
+	if err := s.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := s.RegisterFailure(ctx, "alice", time.Minute); count != 1 {
+		t.Fatalf("failures after reset = %d, want 1", count)
+	}
+}
+
+func TestMemoryLoginAttemptStoreLock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewMemoryLoginAttemptStore()
+	s.now = func() time.Time { return now }
+
+	if left, _ := s.LockedFor(ctx, "alice"); left != 0 {
+		t.Fatalf("unlocked key is locked for %s", left)
+	}
+
+	if err := s.Lock(ctx, "alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(20 * time.Second)
+	if left, _ := s.LockedFor(ctx, "alice"); left != 40*time.Second {
+		t.Fatalf("locked for %s, want 40s", left)
+	}
+	if left, _ := s.LockedFor(ctx, "bob"); left != 0 {
+		t.Fatalf("other key is locked for %s", left)
+	}
+
+	now = now.Add(40 * time.Second)
+	if left, _ := s.LockedFor(ctx, "alice"); left != 0 {
+		t.Fatalf("lock outlived its duration by %s", left)
+	}
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	postListPrefix        = "posts:list:"
+	postListGenerationKey = "posts:list:generation"
+)
+
+// PostListCache names the cached pages of the post list. Keys contain a generation that is
+// bumped whenever a listed post changes, pages of older generations are never read again
+// and expire on their own. A page built before a bump is stored under the old generation.
+type PostListCache interface {
+	// PostListKey returns the key of the page for the query in the current generation
+	PostListKey(ctx context.Context, query string) (string, error)
+	// InvalidatePostLists drops every cached page
+	InvalidatePostLists(ctx context.Context) error
+}
+
+var _ PostListCache = (*Client)(nil)
+
+// PostListKey implements PostListCache.
+func (c *Client) PostListKey(ctx context.Context, query string) (string, error) {
+	generation, err := c.Get(ctx, postListGenerationKey).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	return postListPrefix + strconv.FormatInt(generation, 10) + ":" + query, nil
+}
+
+// InvalidatePostLists implements PostListCache.
+func (c *Client) InvalidatePostLists(ctx context.Context) error {
+	return c.Incr(ctx, postListGenerationKey).Err()
+}
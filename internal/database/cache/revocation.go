@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	revokedTokenPrefix = "auth:revoked:jti:"
+	revokedUserPrefix  = "auth:revoked:user:"
+)
+
+// RevocationStore keeps revoked access tokens until they would have expired anyway.
+// A single token is revoked by its jti, all tokens of a user by a cutoff: tokens with an
+// `iat` (whole seconds) before the cutoff are rejected.
+//
+// NOTE: The cutoff is always later than the second it is set in, so tokens issued in the same
+// second are covered. New tokens take their `iat` from IssueTime, which never returns less
+// than the cutoff, so they aren't mistaken for revoked ones.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, ttl time.Duration) error
+	RevokeUserTokens(ctx context.Context, userID string, before time.Time, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	// IssueTime returns the `iat` for a new token of the user: now in whole seconds, or the
+	// cutoff of the user when that is later
+	IssueTime(ctx context.Context, userID string, now time.Time) (time.Time, error)
+}
+
+var (
+	_ RevocationStore = (*Client)(nil)
+	_ RevocationStore = (*MemoryRevocationStore)(nil)
+)
+
+// RevokeToken implements RevocationStore.
+func (c *Client) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return c.Set(ctx, revokedTokenPrefix+jti, 1, ttl).Err()
+}
+
+// revokeUserScript sets the cutoff to ARGV[1], or past the current one if that isn't earlier.
+// A token issued at the current cutoff must be rejected by the new one. The key lives for
+// ARGV[2] milliseconds after the cutoff.
+var revokeUserScript = redis.NewScript(`
+local cutoff = tonumber(ARGV[1])
+local current = tonumber(redis.call("GET", KEYS[1]))
+if current and current >= cutoff then
+	cutoff = current + 1
+end
+redis.call("SET", KEYS[1], cutoff, "PX", tonumber(ARGV[2]) + math.max(0, cutoff - tonumber(ARGV[3])) * 1000)
+return cutoff
+`)
+
+// RevokeUserTokens implements RevocationStore.
+// NOTE: ttl should be the access token lifetime, older tokens are expired anyway.
+func (c *Client) RevokeUserTokens(ctx context.Context, userID string, before time.Time, ttl time.Duration) error {
+	return revokeUserScript.Run(ctx, c, []string{revokedUserPrefix + userID},
+		before.Unix()+1, ttl.Milliseconds(), time.Now().Unix()).Err()
+}
+
+// IsRevoked implements RevocationStore.
+func (c *Client) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	values, err := c.MGet(ctx, revokedTokenPrefix+jti, revokedUserPrefix+userID).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+
+	if values[1] == nil {
+		return false, nil
+	}
+
+	cutoff, err := strconv.ParseInt(values[1].(string), 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt.Unix() < cutoff, nil
+}
+
+// IssueTime implements RevocationStore.
+func (c *Client) IssueTime(ctx context.Context, userID string, now time.Time) (time.Time, error) {
+	cutoff, err := c.Get(ctx, revokedUserPrefix+userID).Int64()
+	if err != nil && err != redis.Nil {
+		return time.Time{}, err
+	}
+
+	return issueTime(now, cutoff), nil
+}
+
+// issueTime is now in whole seconds, or the cutoff (unix seconds) when that is later
+func issueTime(now time.Time, cutoff int64) time.Time {
+	return time.Unix(max(now.Unix(), cutoff), 0)
+}
+
+// MemoryRevocationStore is an in-process RevocationStore for tests and single instance setups.
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	tokens map[string]time.Time
+	users  map[string]memoryCutoff
+}
+
+type memoryCutoff struct {
+	cutoff    int64
+	expiresAt time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		now:    time.Now,
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]memoryCutoff),
+	}
+}
+
+// RevokeToken implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[jti] = s.now().Add(ttl)
+	return nil
+}
+
+// RevokeUserTokens implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeUserTokens(_ context.Context, userID string, before time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cutoff := before.Unix() + 1
+	if current, ok := s.users[userID]; ok && now.Before(current.expiresAt) && current.cutoff >= cutoff {
+		cutoff = current.cutoff + 1
+	}
+
+	s.users[userID] = memoryCutoff{cutoff: cutoff, expiresAt: time.Unix(cutoff, 0).Add(ttl)}
+	return nil
+}
+
+// IsRevoked implements RevocationStore.
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if expiresAt, ok := s.tokens[jti]; ok {
+		if now.Before(expiresAt) {
+			return true, nil
+		}
+		delete(s.tokens, jti)
+	}
+
+	if cutoff, ok := s.userCutoff(userID, now); ok {
+		return issuedAt.Unix() < cutoff, nil
+	}
+
+	return false, nil
+}
+
+// IssueTime implements RevocationStore.
+func (s *MemoryRevocationStore) IssueTime(_ context.Context, userID string, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff, _ := s.userCutoff(userID, s.now())
+	return issueTime(now, cutoff), nil
+}
+
+// userCutoff returns the unexpired cutoff of the user, the caller holds the lock
+func (s *MemoryRevocationStore) userCutoff(userID string, now time.Time) (int64, bool) {
+	cutoff, ok := s.users[userID]
+	if !ok {
+		return 0, false
+	}
+
+	if !now.Before(cutoff.expiresAt) {
+		delete(s.users, userID)
+		return 0, false
+	}
+
+	return cutoff.cutoff, true
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStoreUserCutoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	s := NewMemoryRevocationStore()
+	s.now = func() time.Time { return now }
+
+	if err := s.RevokeUserTokens(ctx, "alice", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens issued after the revoke, in the same second
+	issued, err := s.IssueTime(ctx, "alice", now.Add(300*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Truncate(time.Second).Add(time.Second); !issued.Equal(want) {
+		t.Fatalf("IssueTime() = %s, want the cutoff %s", issued, want)
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"earlier second", "alice", now.Add(-time.Second), true},
+		{"same second", "alice", now.Truncate(time.Second), true},
+		{"issued after the revoke", "alice", issued, false},
+		{"later second", "alice", now.Add(2 * time.Second), false},
+		{"other user", "bob", now.Add(-time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := s.IsRevoked(ctx, "jti", tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.revoked {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, tt.revoked)
+			}
+		})
+	}
+
+	// Users without a cutoff get the current second
+	if issued, _ := s.IssueTime(ctx, "bob", now); !issued.Equal(now.Truncate(time.Second)) {
+		t.Errorf("IssueTime() without cutoff = %s, want %s", issued, now.Truncate(time.Second))
+	}
+}
+
+// A second revoke within the same second must reject tokens issued after the first one
+func TestMemoryRevocationStoreRepeatedRevoke(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 100_000_000, time.UTC)
+	s := NewMemoryRevocationStore()
+	s.now = func() time.Time { return now }
+
+	if err := s.RevokeUserTokens(ctx, "alice", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	between, _ := s.IssueTime(ctx, "alice", now)
+
+	if err := s.RevokeUserTokens(ctx, "alice", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := s.IssueTime(ctx, "alice", now)
+
+	if revoked, _ := s.IsRevoked(ctx, "jti", "alice", between); !revoked {
+		t.Error("token issued between the revokes is accepted")
+	}
+	if revoked, _ := s.IsRevoked(ctx, "jti", "alice", after); revoked {
+		t.Error("token issued after the second revoke is rejected")
+	}
+}
+
+func TestMemoryRevocationStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewMemoryRevocationStore()
+	s.now = func() time.Time { return now }
+
+	if err := s.RevokeToken(ctx, "jti", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeUserTokens(ctx, "alice", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := s.IsRevoked(ctx, "jti", "bob", now); !revoked {
+		t.Fatal("revoked token is accepted")
+	}
+	if revoked, _ := s.IsRevoked(ctx, "other", "alice", now.Add(-time.Second)); !revoked {
+		t.Fatal("token issued before the cutoff is accepted")
+	}
+
+	// Both revocations end with the lifetime of the tokens
+	now = now.Add(time.Minute + time.Second)
+	if revoked, _ := s.IsRevoked(ctx, "jti", "alice", now.Add(-2*time.Minute)); revoked {
+		t.Fatal("revocations outlived their ttl")
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const timelinePrefix = "feed:timeline:"
+
+// timelineSentinel is the oldest member of a timeline holding every post of the feed.
+// It keeps empty timelines in Redis and is trimmed away once older posts drop out.
+const timelineSentinel = "-"
+
+// TimelineEntry is a post in a timeline, ordered by the time it was published
+type TimelineEntry struct {
+	PostID      string
+	PublishedAt time.Time
+}
+
+// Timeline is the cached head of a feed. Complete is set when no older posts exist.
+type Timeline struct {
+	Entries  []TimelineEntry
+	Complete bool
+}
+
+// TimelineStore keeps the newest post IDs of each user's feed in a capped sorted set.
+// Timelines are only pushed to once they were built, a missing timeline is a cache miss.
+type TimelineStore interface {
+	// PushTimelines adds the post to the existing timelines of the owners, trimming them to size
+	PushTimelines(ctx context.Context, owners []string, entry TimelineEntry, size int) error
+	// MergeTimeline adds a freshly built timeline to the cached one and trims it to size,
+	// complete when entries hold the whole feed. Entries pushed in the meantime are kept.
+	MergeTimeline(ctx context.Context, owner string, entries []TimelineEntry, complete bool, size int, ttl time.Duration) error
+	// ReadTimeline returns up to `count` of the newest entries, nil when the timeline isn't cached
+	ReadTimeline(ctx context.Context, owner string, count int) (*Timeline, error)
+	// DeleteTimeline drops a timeline, e.g. when the authors of the feed changed. It is
+	// rebuilt on the next read.
+	DeleteTimeline(ctx context.Context, owner string) error
+}
+
+var _ TimelineStore = (*Client)(nil)
+
+// pushTimelineScript adds a member to every existing key and trims it to ARGV[3] members
+var pushTimelineScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		redis.call("ZADD", key, ARGV[1], ARGV[2])
+		redis.call("ZREMRANGEBYRANK", key, 0, -tonumber(ARGV[3]) - 1)
+	end
+end
+return 0
+`)
+
+// PushTimelines implements TimelineStore.
+func (c *Client) PushTimelines(ctx context.Context, owners []string, entry TimelineEntry, size int) error {
+	if len(owners) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(owners))
+	for i, owner := range owners {
+		keys[i] = timelinePrefix + owner
+	}
+
+	// One place for the sentinel, it's trimmed first as the oldest member
+	return pushTimelineScript.Run(ctx, c, keys, entry.PublishedAt.UnixMilli(), entry.PostID, size+1).Err()
+}
+
+// MergeTimeline implements TimelineStore.
+func (c *Client) MergeTimeline(ctx context.Context, owner string, entries []TimelineEntry, complete bool, size int, ttl time.Duration) error {
+	key := timelinePrefix + owner
+	members := make([]redis.Z, 0, len(entries)+1)
+	if complete {
+		members = append(members, redis.Z{Score: 0, Member: timelineSentinel})
+	}
+	for _, e := range entries {
+		members = append(members, redis.Z{Score: float64(e.PublishedAt.UnixMilli()), Member: e.PostID})
+	}
+
+	// Like PushTimelines, one place for the sentinel which is trimmed first
+	_, err := c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -int64(size+1)-1)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// DeleteTimeline implements TimelineStore.
+func (c *Client) DeleteTimeline(ctx context.Context, owner string) error {
+	return c.Del(ctx, timelinePrefix+owner).Err()
+}
+
+// ReadTimeline implements TimelineStore.
+func (c *Client) ReadTimeline(ctx context.Context, owner string, count int) (*Timeline, error) {
+	members, err := c.ZRevRangeWithScores(ctx, timelinePrefix+owner, 0, int64(count)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	timeline := &Timeline{Entries: make([]TimelineEntry, 0, len(members))}
+	for _, z := range members {
+		id, _ := z.Member.(string)
+		if id == timelineSentinel {
+			timeline.Complete = true
+			continue
+		}
+		timeline.Entries = append(timeline.Entries, TimelineEntry{PostID: id, PublishedAt: time.UnixMilli(int64(z.Score))})
+	}
+
+	if len(timeline.Entries) > count {
+		timeline.Entries = timeline.Entries[:count]
+	}
+
+	return timeline, nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// API keys have the form `gapi_<prefix>_<secret>`. The prefix (hex) finds the stored key,
+// the SHA-256 hash of the whole key verifies it.
+
+// CreateAPIKey implements Service.
+// Returns the key itself only here, it can't be retrieved later.
+func (s *service) CreateAPIKey(ctx context.Context, payload *CreateAPIKeyPayload) (*APIKey, string, error) {
+	userData, err := s.apiKeyOwner(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New(errors.ErrInvalidRequestBody, "expires_at must be in the future", nil)
+	}
+
+	// A key can't be given more than its owner has
+	roles, err := s.roles.ListUserRoles(ctx, userData.UserID)
+	if err != nil {
+		return nil, "", errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	granted := append(roles.Names(), roles.PermissionNames()...)
+	for _, scope := range payload.Scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, "", errors.New(errors.ErrInvalidRequestBody, fmt.Sprintf("scope '%s' is not granted to you", scope), nil)
+		}
+	}
+
+	prefix, secret, err := generateAPIKeyParts()
+	if err != nil {
+		return nil, "", errors.New(errors.ErrTokenGeneration, "cannot generate api key", err)
+	}
+
+	plain := apiKeyPrefix + "_" + prefix + "_" + secret
+	key := &APIKey{
+		ID:        uuid.New(),
+		UserID:    userData.UserID,
+		Name:      payload.Name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(plain),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(payload.Scopes))),
+		ExpiresAt: payload.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return key, plain, nil
+}
+
+// ListAPIKeys implements Service.
+func (s *service) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	userData, err := s.apiKeyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.repo.ListAPIKeys(ctx, userData.UserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey implements Service.
+func (s *service) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	userData, err := s.apiKeyOwner(ctx)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteAPIKey(ctx, userData.UserID, id)
+	if err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if !deleted {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return nil
+}
+
+// Private helper methods
+// NOTE: Keys are managed with a login only, a leaked key must not be able to mint new ones.
+func (s *service) apiKeyOwner(ctx context.Context) (m.UserDataContext, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return userData, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if userData.APIKeyID != uuid.Nil {
+		return userData, errors.New(errors.ErrUserNotAuthorized, "api keys can't be managed with an api key", nil)
+	}
+
+	return userData, nil
+}
+
+// APIKeyVerifier authenticates requests made with an API key, see middleware.AuthOptions.
+type APIKeyVerifier struct {
+	repo  Repository
+	roles role.Repository
+}
+
+func NewAPIKeyVerifier(r Repository, roles role.Repository) *APIKeyVerifier {
+	return &APIKeyVerifier{repo: r, roles: roles}
+}
+
+var _ m.APIKeyVerifier = (*APIKeyVerifier)(nil)
+
+// VerifyAPIKey implements middleware.APIKeyVerifier.
+// Scopes are matched against the current roles of the user, so revoking a role
+// also takes it away from the user's keys.
+func (v *APIKeyVerifier) VerifyAPIKey(ctx context.Context, plain string) (*m.UserDataContext, error) {
+	parts := strings.SplitN(plain, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, nil
+	}
+
+	key, err := v.repo.GetAPIKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(plain))) != 1 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, nil
+	}
+
+	roles, err := v.roles.ListUserRoles(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	userData := &m.UserDataContext{
+		UserID:      key.UserID,
+		Roles:       []string{},
+		Permissions: []string{},
+		APIKeyID:    key.ID,
+	}
+
+	for _, r := range roles {
+		scopedRole := slices.Contains(key.Scopes, r.Name)
+		if scopedRole {
+			userData.Roles = append(userData.Roles, r.Name)
+		}
+
+		for _, p := range r.Permissions {
+			if (scopedRole || slices.Contains(key.Scopes, p.Name)) && !slices.Contains(userData.Permissions, p.Name) {
+				userData.Permissions = append(userData.Permissions, p.Name)
+			}
+		}
+	}
+
+	if err := v.repo.TouchAPIKey(ctx, key.ID, now, now.Add(-apiKeyTouchInterval)); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to record api key use")
+	}
+
+	return userData, nil
+}
+
+func generateAPIKeyParts() (string, string, error) {
+	b := make([]byte, 8+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(b[:8]), base64.RawURLEncoding.EncodeToString(b[8:]), nil
+}
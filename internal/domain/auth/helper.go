@@ -2,22 +2,32 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
 	"time"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	"example.com/goapi/internal/domain/user"
+	"example.com/goapi/internal/mailer"
+	m "example.com/goapi/internal/middleware"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Private helper methods
-func (s *service) generateTokenPair(ctx context.Context, userID uuid.UUID) (*TokenPair, error) {
-	accessToken, err := s.generateAccessToken(userID)
+func (s *service) generateTokenPair(ctx context.Context, userID uuid.UUID, session *RefreshToken) (*TokenPair, error) {
+	accessToken, err := s.generateAccessToken(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(ctx, userID)
+	refreshToken, err := s.generateRefreshToken(ctx, userID, session)
 	if err != nil {
 		return nil, err
 	}
@@ -29,18 +39,33 @@ func (s *service) generateTokenPair(ctx context.Context, userID uuid.UUID) (*Tok
 }
 
 // Private helper methods
-func (s *service) generateAccessToken(userID uuid.UUID) (string, error) {
+// NOTE: Roles and permissions are loaded on every issuance, so changes apply from the next refresh.
+func (s *service) generateAccessToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	roles, err := s.roles.ListUserRoles(ctx, userID)
+	if err != nil {
+		return "", errors.New(errors.ErrTokenGeneration, "cannot load user roles", err)
+	}
+
+	// Never before the revocation cutoff of the user, or the token would be born revoked
+	issuedAt, err := s.revocations.IssueTime(ctx, userID.String(), time.Now())
+	if err != nil {
+		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
+	}
+
 	claims := JWTClaim{
-		UserID: userID.String(),
-		Roles:  []string{"user"},
+		UserID:      userID.String(),
+		Roles:       roles.Names(),
+		Permissions: roles.PermissionNames(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessToken.Expiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Issuer:    s.conf.Issuer,
+			Audience:  jwt.ClaimStrings{s.conf.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.conf.AccessTTL)),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(s.accessToken.Secret))
+	signedToken, err := s.keys.Sign(claims)
 	if err != nil {
 		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
 	}
@@ -49,32 +74,200 @@ func (s *service) generateAccessToken(userID uuid.UUID) (string, error) {
 }
 
 // Private helper methods
-func (s *service) generateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
-	// Generate a secure random token
-	token := uuid.New().String()
-
-	// Hash the token before storing it
-	hashedToken, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+// NOTE: session carries the device details of the session the new token belongs to.
+func (s *service) generateRefreshToken(ctx context.Context, userID uuid.UUID, session *RefreshToken) (string, error) {
+	token, stored, err := s.newRefreshToken(userID, session)
 	if err != nil {
-		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
+		return "", err
 	}
 
-	// Store in database
-	if err := s.repo.CreateRefreshToken(
-		ctx,
-		userID,
-		string(hashedToken),
-		time.Now().Add(s.refreshToken.Expiration),
-	); err != nil {
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
 		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
 	}
 
 	return token, nil
 }
 
+// Private helper methods
+// Returns a new refresh token and the record to store for it, which holds only its hash.
+func (s *service) newRefreshToken(userID uuid.UUID, session *RefreshToken) (string, *RefreshToken, error) {
+	// Generate a secure random token
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	return token, &RefreshToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		TokenHash:  hashToken(token),
+		ExpiresAt:  now.Add(s.conf.RefreshTTL),
+		SessionID:  session.SessionID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		SignedInAt: session.SignedInAt,
+		LastUsedAt: now,
+	}, nil
+}
+
+// Private helper methods
+func (s *service) sendVerificationEmail(ctx context.Context, u *user.User) error {
+	token, err := s.issueOneTimeToken(ctx, u.ID, PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s/verify-email?token=%s\n\n"+
+				"The link expires in %s. If you did not create an account, you can ignore this email.\n",
+			u.Username, s.appURL, url.QueryEscape(token), emailVerificationTTL,
+		),
+	})
+}
+
+// Private helper methods
+func (s *service) sendPasswordResetEmail(ctx context.Context, u *user.User) error {
+	token, err := s.issueOneTimeToken(ctx, u.ID, PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYou can choose a new password by opening the link below:\n\n%s/reset-password?token=%s\n\n"+
+				"The link expires in %s. If you did not ask for a password reset, you can ignore this email.\n",
+			u.Username, s.appURL, url.QueryEscape(token), passwordResetTTL,
+		),
+	})
+}
+
+// setPassword stores a new password for the user, signs the user out of every session and revokes their API keys
+func (s *service) setPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	hash, err := hashPassword(password, s.conf.BcryptCost)
+	if err != nil {
+		return errors.New(errors.ErrInternalServer, errors.PasswordHashingFailed, err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, userID, hash); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	// Outstanding reset links must not work with the new password anymore
+	if err := s.repo.InvalidateVerificationTokens(ctx, userID, PurposePasswordReset, time.Now()); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return s.signOutEverywhere(ctx, userID)
+}
+
+// Private helper methods
+// Revokes every session, access token and API key of the user. Keys are long lived and
+// not covered by the access token cutoff, so they are deleted.
+func (s *service) signOutEverywhere(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, "failed to revoke tokens", err)
+	}
+
+	if err := s.repo.DeleteAllAPIKeys(ctx, userID); err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, "failed to revoke api keys", err)
+	}
+
+	return s.revokeUserAccessTokens(ctx, userID)
+}
+
+// Private helper methods
+// NOTE: The revocation lives only as long as the token, after that it is expired anyway.
+func (s *service) revokeAccessToken(ctx context.Context, userData m.UserDataContext) error {
+	if userData.TokenID == "" {
+		return nil
+	}
+
+	if err := s.revocations.RevokeToken(ctx, userData.TokenID, time.Until(userData.ExpiresAt)); err != nil {
+		return errors.New(errors.ErrInternalServer, "failed to revoke access token", err)
+	}
+
+	return nil
+}
+
+// Private helper methods
+// Rejects every access token of the user issued until now.
+func (s *service) revokeUserAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	if err := s.revocations.RevokeUserTokens(ctx, userID.String(), time.Now(), s.conf.AccessTTL); err != nil {
+		return errors.New(errors.ErrInternalServer, "failed to revoke access tokens", err)
+	}
+
+	return nil
+}
+
+// Private helper methods
+func (s *service) grantDefaultRole(ctx context.Context, userID uuid.UUID) error {
+	defaultRole, err := s.roles.GetByName(ctx, role.User)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if defaultRole == nil {
+		return errors.New(errors.ErrConfigLoadFailure, fmt.Sprintf(errors.ResourceNotFound, role.User), nil)
+	}
+
+	if _, err := s.roles.Grant(ctx, &role.UserRole{UserID: userID, RoleID: defaultRole.ID}); err != nil {
+		return errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return nil
+}
+
+// newSession starts a new device session for a fresh login
+func newSession(device *DeviceInfo) *RefreshToken {
+	session := &RefreshToken{
+		SessionID:  uuid.New(),
+		SignedInAt: time.Now(),
+	}
+
+	if device != nil {
+		session.DeviceName = device.DeviceName
+		session.UserAgent = device.UserAgent
+		session.IPAddress = device.IPAddress
+	}
+
+	return session
+}
+
+// continueSession carries an existing session over to a rotated token,
+// refreshing the client details it was last used from.
+func continueSession(current *RefreshToken, device *DeviceInfo) *RefreshToken {
+	session := *current
+	if device != nil {
+		if device.UserAgent != "" {
+			session.UserAgent = device.UserAgent
+		}
+		if device.IPAddress != "" {
+			session.IPAddress = device.IPAddress
+		}
+	}
+
+	return &session
+}
+
+// hashToken returns the SHA-256 hex digest of a refresh token.
+// NOTE: Refresh tokens are random and high entropy, so a fast deterministic hash is enough
+// and (unlike bcrypt) lets us look the token up by its hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // Store password related functionality in a separate file [IMP]
-func hashPassword(text string) ([]byte, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(text), bcrypt.DefaultCost)
+func hashPassword(text string, cost int) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(text), cost)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"github.com/rs/zerolog"
+)
+
+// LockoutError is wrapped in the ErrUserBlocked ApiError returned while a login is locked out.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("locked out for %s", e.RetryAfter.Round(time.Second))
+}
+
+// loginKey identifies a login throttling counter and its threshold
+type loginKey struct {
+	key         string
+	maxAttempts int
+}
+
+// NOTE: Accounts are keyed by email, so unknown emails are throttled just like existing ones.
+func (s *service) loginKeys(email string, device *DeviceInfo) []loginKey {
+	keys := []loginKey{{
+		key:         "email:" + strings.ToLower(strings.TrimSpace(email)),
+		maxAttempts: s.conf.LoginMaxAttempts,
+	}}
+
+	if device != nil && device.IPAddress != "" {
+		keys = append(keys, loginKey{key: "ip:" + device.IPAddress, maxAttempts: s.conf.LoginMaxAttemptsIP})
+	}
+
+	return keys
+}
+
+// Private helper methods
+// Returns an ErrUserBlocked error if any of the keys is locked out.
+// NOTE: Store failures are logged and ignored, an unavailable Redis must not block every login.
+func (s *service) checkLoginLockout(ctx context.Context, keys []loginKey) error {
+	var retryAfter time.Duration
+	for _, k := range keys {
+		left, err := s.attempts.LockedFor(ctx, k.key)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to check login lockout")
+			continue
+		}
+
+		retryAfter = max(retryAfter, left)
+	}
+
+	if retryAfter > 0 {
+		return errors.New(errors.ErrUserBlocked, errors.TooManyAttempts, &LockoutError{RetryAfter: retryAfter})
+	}
+
+	return nil
+}
+
+// Private helper methods
+// Counts a failed login and locks keys that reached their threshold.
+// Every failure past the threshold doubles the lockout, up to LoginLockoutMax.
+func (s *service) registerLoginFailure(ctx context.Context, keys []loginKey) {
+	// Keep counting while locked out, so the backoff keeps growing
+	ttl := s.conf.LoginAttemptWindow + s.conf.LoginLockoutMax
+
+	for _, k := range keys {
+		failures, err := s.attempts.RegisterFailure(ctx, k.key, ttl)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to register login failure")
+			continue
+		}
+
+		if failures < int64(k.maxAttempts) {
+			continue
+		}
+
+		if err := s.attempts.Lock(ctx, k.key, s.lockoutDuration(failures-int64(k.maxAttempts))); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to lock login")
+		}
+	}
+}
+
+// Private helper methods
+// NOTE: Only the account counter is reset, a valid login must not clear the counter of a shared IP.
+func (s *service) resetLoginFailures(ctx context.Context, keys []loginKey) {
+	if err := s.attempts.Reset(ctx, keys[0].key); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to reset login failures")
+	}
+}
+
+func (s *service) lockoutDuration(excess int64) time.Duration {
+	d := s.conf.LoginLockoutBase
+	for i := int64(0); i < excess && d < s.conf.LoginLockoutMax; i++ {
+		d *= 2
+	}
+
+	return min(d, s.conf.LoginLockoutMax)
+}
@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/role"
+	"example.com/goapi/internal/domain/user"
+	"example.com/goapi/internal/jwtkeys"
+	"example.com/goapi/internal/mailer"
+	"example.com/goapi/pkg/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryRepo is an in-memory Repository. Methods the tests don't need are left to the
+// embedded interface and panic when called.
+type memoryRepo struct {
+	Repository
+
+	mu            sync.Mutex
+	users         map[uuid.UUID]*user.User
+	refreshTokens map[string]*RefreshToken
+	identities    map[uuid.UUID]*Identity
+	apiKeys       map[uuid.UUID]*APIKey
+	verifications []*VerificationToken
+
+	// Called by GetRefreshTokenByHash after the lookup, e.g. to line up concurrent refreshes
+	afterRefreshLookup func()
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{
+		users:         make(map[uuid.UUID]*user.User),
+		refreshTokens: make(map[string]*RefreshToken),
+		identities:    make(map[uuid.UUID]*Identity),
+		apiKeys:       make(map[uuid.UUID]*APIKey),
+	}
+}
+
+func (r *memoryRepo) GetByID(_ context.Context, id uuid.UUID) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (r *memoryRepo) UpdatePassword(_ context.Context, userID uuid.UUID, hash []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[userID]; ok {
+		u.Password = hash
+	}
+	return nil
+}
+
+func (r *memoryRepo) InvalidateVerificationTokens(_ context.Context, userID uuid.UUID, purpose string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.verifications {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) UseTOTPStep(_ context.Context, userID uuid.UUID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPLastStep >= step {
+		return false, nil
+	}
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+func (r *memoryRepo) CreateRefreshToken(_ context.Context, token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *token
+	r.refreshTokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetRefreshTokenByHash(_ context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	token, ok := r.refreshTokens[tokenHash]
+	var copied RefreshToken
+	if ok {
+		copied = *token
+	}
+	r.mu.Unlock()
+
+	if r.afterRefreshLookup != nil {
+		r.afterRefreshLookup()
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	return &copied, nil
+}
+
+func (r *memoryRepo) RotateRefreshToken(_ context.Context, tokenHash string, next *RefreshToken) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok || token.Revoked {
+		return false, nil
+	}
+	token.Revoked = true
+
+	copied := *next
+	r.refreshTokens[next.TokenHash] = &copied
+	return true, nil
+}
+
+func (r *memoryRepo) RevokeAllRefreshTokens(_ context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) CreateIdentity(_ context.Context, identity *Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *identity
+	r.identities[identity.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetIdentity(_ context.Context, provider, subject string) (*Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepo) ListIdentities(_ context.Context, userID uuid.UUID) ([]*Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identities := []*Identity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			copied := *identity
+			identities = append(identities, &copied)
+		}
+	}
+	return identities, nil
+}
+
+func (r *memoryRepo) TouchIdentity(_ context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if identity, ok := r.identities[id]; ok {
+		identity.LastLoginAt = &at
+	}
+	return nil
+}
+
+func (r *memoryRepo) DeleteIdentity(_ context.Context, userID, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[id]
+	if !ok || identity.UserID != userID {
+		return false, nil
+	}
+	delete(r.identities, id)
+	return true, nil
+}
+
+func (r *memoryRepo) CreateAPIKey(_ context.Context, key *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *key
+	r.apiKeys[key.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetAPIKeyByPrefix(_ context.Context, prefix string) (*APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.apiKeys {
+		if key.Prefix == prefix {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepo) TouchAPIKey(_ context.Context, id uuid.UUID, at, olderThan time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.apiKeys[id]; ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(olderThan)) {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+func (r *memoryRepo) DeleteAllAPIKeys(_ context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, key := range r.apiKeys {
+		if key.UserID == userID {
+			delete(r.apiKeys, id)
+		}
+	}
+	return nil
+}
+
+// activeRefreshTokens counts the refresh tokens of the user that are not revoked
+func (r *memoryRepo) activeRefreshTokens(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID && !token.Revoked {
+			count++
+		}
+	}
+	return count
+}
+
+// memoryRoles grants no roles to anyone
+type memoryRoles struct {
+	role.Repository
+}
+
+func (memoryRoles) ListUserRoles(context.Context, uuid.UUID) (role.Roles, error) {
+	return role.Roles{}, nil
+}
+
+// newTestService creates a service with in-memory dependencies
+func newTestService(t *testing.T, repo Repository, idps map[string]*oidc.Client) *service {
+	t.Helper()
+
+	ks, err := jwtkeys.New(&config.ConfJWT{Algorithm: "EdDSA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.ConfAuth{
+		SecretKey:          "test-secret",
+		AccessTTL:          15 * time.Minute,
+		RefreshTTL:         time.Hour,
+		BcryptCost:         bcrypt.MinCost,
+		Issuer:             "goapi",
+		Audience:           "goapi",
+		LoginMaxAttempts:   5,
+		LoginMaxAttemptsIP: 20,
+		LoginAttemptWindow: 15 * time.Minute,
+		LoginLockoutBase:   time.Minute,
+		LoginLockoutMax:    time.Hour,
+	}
+
+	return NewService(repo, memoryRoles{}, mailer.NewLogMailer("test@example.com"), "http://localhost",
+		ks, cache.NewMemoryRevocationStore(), cache.NewMemoryLoginAttemptStore(), idps, conf).(*service)
+}
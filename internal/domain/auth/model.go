@@ -5,6 +5,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type RegisterUserPayload struct {
@@ -23,22 +24,279 @@ type RefreshTokenPayload struct {
 	UserID  uuid.UUID `json:"user_id"`
 }
 
-type TokenConfig struct {
-	Secret     string
-	Expiration time.Duration
+// RefreshToken is a single refresh token of a device session.
+// A session keeps its SessionID across token rotations.
+type RefreshToken struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash  string    `gorm:"type:text;not null;index"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	Revoked    bool      `gorm:"default:false"`
+	SessionID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	DeviceName string    `gorm:"size:255;not null;default:''"`
+	UserAgent  string    `gorm:"type:text;not null;default:''"`
+	IPAddress  string    `gorm:"size:64;not null;default:''"`
+	SignedInAt time.Time `gorm:"not null"`
+	LastUsedAt time.Time `gorm:"not null"`
 }
 
-type RefreshToken struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
-	TokenHash string    `gorm:"type:text;not null;index"`
-	ExpiresAt time.Time `gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	Revoked   bool      `gorm:"default:false"`
+// DeviceInfo describes the client a session is created or used from
+type DeviceInfo struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// SessionDTO represents an active login session of a user
+type SessionDTO struct {
+	ID         string `json:"id"`
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	SignedInAt string `json:"signed_in_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// ToSessionDto converts the active refresh token of a session into a SessionDTO
+func (t *RefreshToken) ToSessionDto() *SessionDTO {
+	return &SessionDTO{
+		ID:         t.SessionID.String(),
+		DeviceName: t.DeviceName,
+		UserAgent:  t.UserAgent,
+		IPAddress:  t.IPAddress,
+		SignedInAt: t.SignedInAt.Format("2006-01-02 15:04:05"),
+		LastUsedAt: t.LastUsedAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:  t.ExpiresAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Purposes of a VerificationToken
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+// Purpose of the signed challenge returned by Login when two-factor authentication is enabled
+const purposeTwoFactorChallenge = "two_factor_challenge"
+
+const (
+	// How long a user has to enter the code after the password was accepted
+	twoFactorChallengeTTL = 5 * time.Minute
+	// Number of recovery codes generated on enrollment
+	recoveryCodeCount = 10
+	// Accepted clock drift of authenticators in TOTP steps
+	totpSkew = 1
+)
+
+// Purpose of the signed state kept in a cookie during a login with an external provider
+const purposeOIDCState = "oidc_state"
+
+// How long a user has to complete the login at an external provider
+const oidcStateTTL = 10 * time.Minute
+
+// How long a token sent by email stays valid
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = 1 * time.Hour
+)
+
+// VerificationToken is a single-use token sent to the user by email
+type VerificationToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash string     `gorm:"type:text;not null;unique"`
+	Purpose   string     `gorm:"size:32;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+type VerifyEmailPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// RecoveryCode is a single-use code to pass two-factor authentication without the authenticator
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	CodeHash  string     `gorm:"type:text;not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// LoginResult is either a token pair or, with two-factor authentication enabled,
+// a challenge token to exchange together with a code for the token pair.
+type LoginResult struct {
+	Tokens         *TokenPair
+	ChallengeToken string
+}
+
+// TwoFactorRequired reports whether the login has to be completed with a second factor
+func (r *LoginResult) TwoFactorRequired() bool {
+	return r.Tokens == nil
+}
+
+// TwoFactorSetup is returned when enrollment starts, the URI is usually shown as a QR code
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorLoginPayload struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"`
+	DeviceName     string `json:"device_name" validate:"max=255"`
+}
+
+type TwoFactorCodePayload struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type DisableTwoFactorPayload struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// Identity links a user to the subject of an external OpenID Connect provider
+type Identity struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Provider    string     `gorm:"size:64;not null"`
+	Subject     string     `gorm:"size:255;not null"`
+	Email       string     `gorm:"size:255;not null;default:''"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	LastLoginAt *time.Time `gorm:"default:null"`
+}
+
+// IdentityDTO represents a linked external account
+type IdentityDTO struct {
+	ID          string  `json:"id"`
+	Provider    string  `json:"provider"`
+	Email       string  `json:"email"`
+	CreatedAt   string  `json:"created_at"`
+	LastLoginAt *string `json:"last_login_at"`
+}
+
+// ToDto converts an Identity into an IdentityDTO
+func (i *Identity) ToDto() *IdentityDTO {
+	dto := &IdentityDTO{
+		ID:        i.ID.String(),
+		Provider:  i.Provider,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	if i.LastLoginAt != nil {
+		lastLoginAt := i.LastLoginAt.Format("2006-01-02 15:04:05")
+		dto.LastLoginAt = &lastLoginAt
+	}
+
+	return dto
+}
+
+// OIDCAuthorization starts a login or link with an external provider. The client is sent
+// to URL, State has to come back with the callback (it is stored in a cookie).
+type OIDCAuthorization struct {
+	URL       string
+	State     string
+	ExpiresAt time.Time
+}
+
+// OIDCCallback is the result of a provider callback: a login or a newly linked identity
+type OIDCCallback struct {
+	Login    *LoginResult
+	Identity *Identity
+}
+
+// Prefix of every API key, it makes leaked keys easy to find with secret scanners
+const apiKeyPrefix = "gapi"
+
+// Last use of an API key is recorded at most once per interval
+const apiKeyTouchInterval = time.Minute
+
+// APIKey is a personal key of a user for machine clients.
+// Scopes are role or permission names, limited to the ones the user has.
+type APIKey struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Name       string         `gorm:"size:100;not null"`
+	Prefix     string         `gorm:"size:16;not null;unique"`
+	KeyHash    string         `gorm:"type:text;not null"`
+	Scopes     pq.StringArray `gorm:"type:text[]"`
+	ExpiresAt  *time.Time     `gorm:"default:null"`
+	LastUsedAt *time.Time     `gorm:"default:null"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime"`
+}
+
+// APIKeyDTO represents an API key without its secret
+type APIKeyDTO struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedAPIKeyDTO is returned once on creation, the key can't be retrieved later
+type CreatedAPIKeyDTO struct {
+	*APIKeyDTO
+	Key string `json:"key"`
+}
+
+// ToDto converts an APIKey into an APIKeyDTO
+func (k *APIKey) ToDto() *APIKeyDTO {
+	dto := &APIKeyDTO{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	if k.ExpiresAt != nil {
+		expiresAt := k.ExpiresAt.Format("2006-01-02 15:04:05")
+		dto.ExpiresAt = &expiresAt
+	}
+
+	if k.LastUsedAt != nil {
+		lastUsedAt := k.LastUsedAt.Format("2006-01-02 15:04:05")
+		dto.LastUsedAt = &lastUsedAt
+	}
+
+	return dto
+}
+
+type CreateAPIKeyPayload struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required,max=64"`
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type JWTClaim struct {
-	UserID string   `json:"userID"`
-	Roles  []string `json:"roles"`
+	UserID      string   `json:"userID"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/oidc"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// oidcState is kept in a signed cookie between StartOIDC and FinishOIDC, so no server side
+// storage is needed. LinkUserID is set when an authenticated user links a provider.
+type oidcState struct {
+	Provider   string `json:"p"`
+	State      string `json:"s"`
+	Nonce      string `json:"n"`
+	Verifier   string `json:"v"`
+	LinkUserID string `json:"u,omitempty"`
+	ExpiresAt  int64  `json:"e"`
+}
+
+// StartOIDC implements Service.
+// With link set the identity is linked to the authenticated user instead of signing in.
+func (s *service) StartOIDC(ctx context.Context, provider string, link bool) (*OIDCAuthorization, error) {
+	client, err := s.identityProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &oidcState{Provider: provider}
+	if link {
+		userData, ok := m.GetUserDetailsFromContext(ctx)
+		if !ok {
+			return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+		}
+		st.LinkUserID = userData.UserID.String()
+	}
+
+	for _, v := range []*string{&st.State, &st.Nonce} {
+		if *v, err = oidc.RandomString(32); err != nil {
+			return nil, errors.New(errors.ErrTokenGeneration, "cannot generate state", err)
+		}
+	}
+
+	if st.Verifier, err = oidc.NewCodeVerifier(); err != nil {
+		return nil, errors.New(errors.ErrTokenGeneration, "cannot generate state", err)
+	}
+
+	expiresAt := time.Now().Add(oidcStateTTL)
+	st.ExpiresAt = expiresAt.Unix()
+
+	authURL, err := client.AuthCodeURL(ctx, st.State, st.Nonce, oidc.CodeChallengeS256(st.Verifier))
+	if err != nil {
+		return nil, errors.New(errors.ErrExternalAPIFailure, "identity provider unavailable", err)
+	}
+
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return nil, errors.New(errors.ErrJSONEncodeFailure, errors.JSONEncodeFailure, err)
+	}
+
+	return &OIDCAuthorization{
+		URL:       authURL,
+		State:     signPayload(s.conf.SecretKey, purposeOIDCState, payload),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// FinishOIDC implements Service.
+// NOTE: Identities are never linked to existing accounts by email, the email of a provider
+// can't be trusted to belong to the account owner. Users link providers explicitly.
+func (s *service) FinishOIDC(ctx context.Context, provider, code, state, signedState string, device *DeviceInfo) (*OIDCCallback, error) {
+	st, err := s.parseOIDCState(provider, state, signedState)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.identityProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := client.Exchange(ctx, code, st.Verifier)
+	if err != nil {
+		return nil, errors.New(errors.ErrExternalAPIFailure, "code exchange failed", err)
+	}
+
+	idToken, err := client.VerifyIDToken(ctx, tokens.IDToken, st.Nonce)
+	if err != nil {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid id token", err)
+	}
+
+	identity, err := s.repo.GetIdentity(ctx, provider, idToken.Subject)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if st.LinkUserID != "" {
+		identity, err = s.linkIdentity(ctx, st.LinkUserID, identity, provider, idToken)
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCCallback{Identity: identity}, nil
+	}
+
+	var userID uuid.UUID
+	if identity != nil {
+		userID = identity.UserID
+		if err := s.repo.TouchIdentity(ctx, identity.ID, time.Now()); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to update identity last login")
+		}
+	} else {
+		userID, err = s.createUserFromIdentity(ctx, provider, idToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil {
+		return nil, errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userID), nil)
+	}
+
+	// The provider replaces the password, not the second factor
+	if user.TOTPEnabled {
+		return &OIDCCallback{Login: &LoginResult{ChallengeToken: s.issueChallengeToken(user.ID)}}, nil
+	}
+
+	pair, err := s.generateTokenPair(ctx, user.ID, newSession(device))
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCCallback{Login: &LoginResult{Tokens: pair}}, nil
+}
+
+// ListIdentities implements Service.
+func (s *service) ListIdentities(ctx context.Context) ([]*Identity, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	identities, err := s.repo.ListIdentities(ctx, userData.UserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity implements Service.
+func (s *service) UnlinkIdentity(ctx context.Context, id uuid.UUID) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	identities, err := s.repo.ListIdentities(ctx, user.ID)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	// Users created through a provider have no password, they must not lock themselves out
+	if len(user.Password) == 0 && len(identities) <= 1 {
+		return errors.New(errors.ErrInvalidRequestBody, "cannot unlink the only sign-in method, reset your password first", nil)
+	}
+
+	deleted, err := s.repo.DeleteIdentity(ctx, user.ID, id)
+	if err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if !deleted {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return nil
+}
+
+// Private helper methods
+func (s *service) identityProvider(name string) (*oidc.Client, error) {
+	client, ok := s.idps[name]
+	if !ok {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, name), nil)
+	}
+
+	return client, nil
+}
+
+// Private helper methods
+func (s *service) parseOIDCState(provider, state, signedState string) (*oidcState, error) {
+	payload, ok := verifyPayload(s.conf.SecretKey, purposeOIDCState, signedState)
+	if !ok {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid state", nil)
+	}
+
+	st := &oidcState{}
+	if err := json.Unmarshal(payload, st); err != nil {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid state", err)
+	}
+
+	if st.Provider != provider || subtle.ConstantTimeCompare([]byte(st.State), []byte(state)) != 1 {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid state", nil)
+	}
+
+	if time.Now().After(time.Unix(st.ExpiresAt, 0)) {
+		return nil, errors.New(errors.ErrAuthTokenExpired, "state expired", nil)
+	}
+
+	return st, nil
+}
+
+// Private helper methods
+func (s *service) linkIdentity(ctx context.Context, linkUserID string, existing *Identity, provider string, idToken *oidc.IDToken) (*Identity, error) {
+	userID, err := uuid.Parse(linkUserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid state", err)
+	}
+
+	if existing != nil {
+		if existing.UserID != userID {
+			return nil, errors.New(errors.ErrDBDuplicateEntry, "identity is already linked to another account", nil)
+		}
+		return existing, nil
+	}
+
+	identity := &Identity{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Provider: provider,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	}
+
+	if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+		return nil, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return identity, nil
+}
+
+// Private helper methods
+// Creates a user without password for a first login with a provider.
+func (s *service) createUserFromIdentity(ctx context.Context, provider string, idToken *oidc.IDToken) (uuid.UUID, error) {
+	// Unverified emails could block the real owner from registering
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return uuid.Nil, errors.New(errors.ErrUserNotAuthorized, "identity provider did not return a verified email", nil)
+	}
+
+	existing, err := s.repo.GetByEmail(ctx, idToken.Email)
+	if err != nil {
+		return uuid.Nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if existing != nil {
+		return uuid.Nil, errors.New(errors.ErrEmailAlreadyUsed, "an account with this email already exists, sign in and link the provider", nil)
+	}
+
+	username, err := s.uniqueUsername(ctx, idToken)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	user := &user.User{
+		ID:         uuid.New(),
+		Username:   username,
+		Email:      idToken.Email,
+		Password:   []byte{},
+		IsVerified: true,
+	}
+
+	identity := &Identity{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     idToken.Subject,
+		Email:       idToken.Email,
+		LastLoginAt: &now,
+	}
+
+	if err := s.repo.CreateWithIdentity(ctx, user, identity); err != nil {
+		return uuid.Nil, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	if err := s.grantDefaultRole(ctx, user.ID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return user.ID, nil
+}
+
+// Private helper methods
+// Derives a username from the ID token, adding a random suffix when it is taken.
+func (s *service) uniqueUsername(ctx context.Context, idToken *oidc.IDToken) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(idToken.Email, "@")
+	}
+
+	base = strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, base)
+
+	if len(base) > 32 {
+		base = base[:32]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for range 5 {
+		exists, err := s.repo.UsernameExists(ctx, username)
+		if err != nil {
+			return "", errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+		}
+
+		if !exists {
+			return username, nil
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+		if err != nil {
+			return "", errors.New(errors.ErrInternalServer, errors.SomethingWentWrong, err)
+		}
+		username = fmt.Sprintf("%s%06d", base, n.Int64())
+	}
+
+	return "", errors.New(errors.ErrUsernameTaken, "cannot find a free username", nil)
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/oidc"
+	"example.com/goapi/pkg/oidc/oidctest"
+	"github.com/google/uuid"
+)
+
+// newOIDCTestService creates a service with the test provider registered as "test"
+func newOIDCTestService(t *testing.T, repo Repository) (*service, *oidctest.Provider) {
+	t.Helper()
+
+	p := oidctest.NewProvider("goapi")
+	t.Cleanup(p.Close)
+
+	client := oidc.NewClient(oidc.Config{
+		IssuerURL:   p.Issuer(),
+		ClientID:    p.ClientID,
+		RedirectURL: "http://localhost/api/v1/auth/oidc/test/callback",
+		Scopes:      []string{"openid", "email"},
+	}, p.Server.Client())
+
+	return newTestService(t, repo, map[string]*oidc.Client{"test": client}), p
+}
+
+// withUser returns a context of the user as set by the authentication middleware
+func withUser(userID uuid.UUID) context.Context {
+	return m.WithUserDetails(context.Background(), m.UserDataContext{UserID: userID})
+}
+
+// completeOIDC runs the flow from StartOIDC to FinishOIDC with the identity signing in at the provider
+func completeOIDC(t *testing.T, s *service, p *oidctest.Provider, ctx context.Context, link bool, identity oidctest.Identity) (*OIDCCallback, error) {
+	t.Helper()
+
+	authz, err := s.StartOIDC(ctx, "test", link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := p.Authorize(authz.URL, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse(authz.URL)
+	return s.FinishOIDC(context.Background(), "test", code, u.Query().Get("state"), authz.State, nil)
+}
+
+func assertErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != code {
+		t.Fatalf("err = %v, want %s", err, code)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	repo := newMemoryRepo()
+	s, p := newOIDCTestService(t, repo)
+	alice, bob := uuid.New(), uuid.New()
+	identity := oidctest.Identity{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true}
+
+	callback, err := completeOIDC(t, s, p, withUser(alice), true, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Login != nil || callback.Identity == nil {
+		t.Fatalf("callback = %+v, want a linked identity", callback)
+	}
+	if callback.Identity.UserID != alice || callback.Identity.Provider != "test" || callback.Identity.Subject != "sub-1" {
+		t.Fatalf("linked identity = %+v", callback.Identity)
+	}
+
+	// Linking again is a no-op for the same user
+	if _, err := completeOIDC(t, s, p, withUser(alice), true, identity); err != nil {
+		t.Fatal(err)
+	}
+	if identities, _ := repo.ListIdentities(context.Background(), alice); len(identities) != 1 {
+		t.Fatalf("identities of alice = %d, want 1", len(identities))
+	}
+
+	_, err = completeOIDC(t, s, p, withUser(bob), true, identity)
+	assertErrorCode(t, err, errors.ErrDBDuplicateEntry)
+}
+
+func TestLinkedIdentitySignsIn(t *testing.T) {
+	repo := newMemoryRepo()
+	s, p := newOIDCTestService(t, repo)
+	alice := &user.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Password: []byte("hash")}
+	repo.users[alice.ID] = alice
+	identity := oidctest.Identity{Subject: "sub-1", Email: "alice@example.com"}
+
+	if _, err := completeOIDC(t, s, p, withUser(alice.ID), true, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	callback, err := completeOIDC(t, s, p, context.Background(), false, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Login == nil || callback.Login.Tokens == nil {
+		t.Fatalf("callback = %+v, want tokens", callback)
+	}
+	if got := repo.activeRefreshTokens(alice.ID); got != 1 {
+		t.Fatalf("active refresh tokens of alice = %d, want 1", got)
+	}
+}
+
+func TestFinishOIDCRejectsForeignState(t *testing.T) {
+	s, p := newOIDCTestService(t, newMemoryRepo())
+	identity := oidctest.Identity{Subject: "sub-1", Email: "alice@example.com"}
+
+	authz, err := s.StartOIDC(context.Background(), "test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := p.Authorize(authz.URL, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The state of the callback must be the one of the signed cookie
+	_, err = s.FinishOIDC(context.Background(), "test", code, "forged", authz.State, nil)
+	assertErrorCode(t, err, errors.ErrAuthTokenInvalid)
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   []byte
+		identities int
+		wantErr    string
+	}{
+		{"only sign-in method", []byte{}, 1, errors.ErrInvalidRequestBody},
+		{"other identity left", []byte{}, 2, ""},
+		{"password left", []byte("hash"), 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepo()
+			s := newTestService(t, repo, nil)
+			u := &user.User{ID: uuid.New(), Username: "alice", Password: tt.password}
+			repo.users[u.ID] = u
+
+			var ids []uuid.UUID
+			for range tt.identities {
+				identity := &Identity{ID: uuid.New(), UserID: u.ID, Provider: "test", Subject: uuid.NewString()}
+				repo.identities[identity.ID] = identity
+				ids = append(ids, identity.ID)
+			}
+
+			err := s.UnlinkIdentity(withUser(u.ID), ids[0])
+			if tt.wantErr != "" {
+				assertErrorCode(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := repo.identities[ids[0]]; ok {
+				t.Fatal("identity was not deleted")
+			}
+		})
+	}
+}
+
+func TestUnlinkIdentityOfOtherUser(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	alice := &user.User{ID: uuid.New(), Username: "alice", Password: []byte("hash")}
+	repo.users[alice.ID] = alice
+
+	identity := &Identity{ID: uuid.New(), UserID: uuid.New(), Provider: "test", Subject: "sub-1"}
+	repo.identities[identity.ID] = identity
+
+	err := s.UnlinkIdentity(withUser(alice.ID), identity.ID)
+	assertErrorCode(t, err, errors.ErrDBNoRows)
+	if _, ok := repo.identities[identity.ID]; !ok {
+		t.Fatal("identity of another user was deleted")
+	}
+}
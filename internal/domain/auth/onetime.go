@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"github.com/google/uuid"
+)
+
+// One-time tokens have the form `base64(payload).base64(signature)` where the payload is
+// userID (16 bytes) | expiry as unix seconds (8 bytes) | random nonce (16 bytes)
+// and the signature is the HMAC-SHA256 of the purpose and the encoded payload.
+// NOTE: The signature lets us reject forged or expired tokens without hitting the database,
+// the stored hash makes them single use.
+const oneTimePayloadSize = 16 + 8 + 16
+
+// signPayload signs payload for the given purpose with secret
+func signPayload(secret, purpose string, payload []byte) string {
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(payloadSignature(secret, purpose, encoded))
+}
+
+// verifyPayload checks the signature of a token created by signPayload and returns its payload
+func verifyPayload(secret, purpose, token string) ([]byte, bool) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, false
+	}
+
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, payloadSignature(secret, purpose, encoded)) {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func payloadSignature(secret, purpose, encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{'.'})
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// issueOneTimeToken creates a signed, expiring token for the purpose and stores its hash
+func (s *service) issueOneTimeToken(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	expiresAt := time.Now().Add(ttl)
+
+	payload := make([]byte, oneTimePayloadSize)
+	copy(payload[:16], userID[:])
+	binary.BigEndian.PutUint64(payload[16:24], uint64(expiresAt.Unix()))
+	if _, err := rand.Read(payload[24:]); err != nil {
+		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
+	}
+
+	token := signPayload(s.conf.SecretKey, purpose, payload)
+	if err := s.repo.CreateVerificationToken(ctx, &VerificationToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		Purpose:   purpose,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", errors.New(errors.ErrTokenGeneration, "cannot generate token", err)
+	}
+
+	return token, nil
+}
+
+// consumeOneTimeToken validates a token issued for the purpose, marks it as used
+// and returns the user it was issued to.
+func (s *service) consumeOneTimeToken(ctx context.Context, token, purpose string) (uuid.UUID, error) {
+	payload, ok := verifyPayload(s.conf.SecretKey, purpose, token)
+	if !ok || len(payload) != oneTimePayloadSize {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	now := time.Now()
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0)
+	if now.After(expiresAt) {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenExpired, "token expired", nil)
+	}
+
+	stored, err := s.repo.GetVerificationTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return uuid.Nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if stored == nil || stored.Purpose != purpose || stored.UsedAt != nil ||
+		!bytes.Equal(stored.UserID[:], payload[:16]) {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	consumed, err := s.repo.ConsumeVerificationToken(ctx, stored.ID, now)
+	if err != nil {
+		return uuid.Nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	if !consumed {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	return stored.UserID, nil
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"github.com/google/uuid"
+)
+
+// signIn starts a session for a new user and returns its token pair
+func signIn(t *testing.T, s *service) (uuid.UUID, *TokenPair) {
+	t.Helper()
+
+	userID := uuid.New()
+	pair, err := s.generateTokenPair(context.Background(), userID, newSession(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userID, pair
+}
+
+func assertUnauthorized(t *testing.T, err error) {
+	t.Helper()
+
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != errors.ErrUnauthorized {
+		t.Fatalf("err = %v, want %s", err, errors.ErrUnauthorized)
+	}
+}
+
+func TestRefreshTokensRotates(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	userID, pair := signIn(t, s)
+
+	rotated, err := s.RefreshTokens(context.Background(), pair.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotated.RefreshToken == pair.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	if got := repo.activeRefreshTokens(userID); got != 1 {
+		t.Fatalf("active refresh tokens = %d, want 1", got)
+	}
+
+	if _, err := s.RefreshTokens(context.Background(), rotated.RefreshToken, nil); err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokensReuseRevokesFamily(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	userID, pair := signIn(t, s)
+
+	rotated, err := s.RefreshTokens(context.Background(), pair.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old token is presented again, e.g. by whoever stole it
+	_, err = s.RefreshTokens(context.Background(), pair.RefreshToken, nil)
+	assertUnauthorized(t, err)
+
+	if got := repo.activeRefreshTokens(userID); got != 0 {
+		t.Fatalf("active refresh tokens = %d, want 0", got)
+	}
+
+	_, err = s.RefreshTokens(context.Background(), rotated.RefreshToken, nil)
+	assertUnauthorized(t, err)
+}
+
+func TestRefreshTokensConcurrentReuse(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	userID, pair := signIn(t, s)
+
+	// Both refreshes read the token before either revokes it
+	const concurrent = 2
+	var lookups sync.WaitGroup
+	lookups.Add(concurrent)
+	repo.afterRefreshLookup = func() {
+		lookups.Done()
+		lookups.Wait()
+	}
+
+	errs := make([]error, concurrent)
+	var done sync.WaitGroup
+	for i := range concurrent {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_, errs[i] = s.RefreshTokens(context.Background(), pair.RefreshToken, nil)
+		}()
+	}
+	done.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assertUnauthorized(t, err)
+	}
+
+	if succeeded != 1 {
+		t.Fatalf("%d refreshes succeeded, want 1", succeeded)
+	}
+
+	// The token minted by the refresh that won is part of the revoked family as well
+	if got := repo.activeRefreshTokens(userID); got != 0 {
+		t.Fatalf("active refresh tokens = %d, want 0", got)
+	}
+}
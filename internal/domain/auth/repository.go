@@ -11,10 +11,52 @@ import (
 type Repository interface {
 	Create(ctx context.Context, user *user.User) error
 	GetByEmail(ctx context.Context, email string) (*user.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*user.User, error)
+	MarkVerified(ctx context.Context, userID uuid.UUID) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, hash []byte) error
 
 	// Token methods
-	CreateRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
-	GetRefreshTokenHash(ctx context.Context, userID uuid.UUID) (*RefreshToken, error)
-	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// RotateRefreshToken revokes the token if it is not revoked yet and stores next along with it.
+	// False means it was used already, next isn't stored then.
+	RotateRefreshToken(ctx context.Context, tokenHash string, next *RefreshToken) (bool, error)
 	RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error
+
+	// Session methods
+	ListActiveSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]*RefreshToken, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error)
+
+	// Verification token methods
+	CreateVerificationToken(ctx context.Context, token *VerificationToken) error
+	GetVerificationTokenByHash(ctx context.Context, tokenHash string) (*VerificationToken, error)
+	ConsumeVerificationToken(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error)
+	InvalidateVerificationTokens(ctx context.Context, userID uuid.UUID, purpose string, usedAt time.Time) error
+
+	// Two-factor authentication methods
+	SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, codes []*RecoveryCode) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+	// UseTOTPStep records the step of an accepted code, false if it (or a later one) was used already
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// External identity methods
+	CreateWithIdentity(ctx context.Context, user *user.User, identity *Identity) error
+	UsernameExists(ctx context.Context, username string) (bool, error)
+	CreateIdentity(ctx context.Context, identity *Identity) error
+	GetIdentity(ctx context.Context, provider, subject string) (*Identity, error)
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]*Identity, error)
+	TouchIdentity(ctx context.Context, id uuid.UUID, at time.Time) error
+	DeleteIdentity(ctx context.Context, userID, id uuid.UUID) (bool, error)
+
+	// API key methods
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+	// TouchAPIKey records the last use, unless the recorded one is newer than olderThan
+	TouchAPIKey(ctx context.Context, id uuid.UUID, at, olderThan time.Time) error
+	DeleteAPIKey(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	DeleteAllAPIKeys(ctx context.Context, userID uuid.UUID) error
 }
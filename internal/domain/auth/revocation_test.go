@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// Tokens issued within the same second as a sign out must be told apart from earlier ones
+func TestSignOutUserRevokesEarlierAccessTokens(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	ctx := context.Background()
+
+	authn := m.NewAuthenticator(m.AuthOptions{
+		Keyfunc:     s.keys.Keyfunc,
+		Algorithms:  s.keys.Algorithms(),
+		Issuer:      s.conf.Issuer,
+		Audience:    s.conf.Audience,
+		Revocations: s.revocations,
+	})
+	handler := authn.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	status := func(accessToken string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	userID := uuid.New()
+	repo.users[userID] = &user.User{ID: userID}
+
+	before, err := s.generateTokenPair(ctx, userID, newSession(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SignOutUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.generateTokenPair(ctx, userID, newSession(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := status(before.AccessToken); got != http.StatusUnauthorized {
+		t.Errorf("token issued before the sign out: status = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := status(after.AccessToken); got != http.StatusOK {
+		t.Errorf("token issued after the sign out: status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+// API keys don't expire with the access token cutoff, signing out everywhere must revoke them
+func TestSignOutEverywhereRevokesAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		signOut func(ctx context.Context, s *service, userID uuid.UUID) error
+	}{
+		{"admin sign out", func(ctx context.Context, s *service, userID uuid.UUID) error {
+			return s.SignOutUser(ctx, userID)
+		}},
+		{"revoke all sessions", func(ctx context.Context, s *service, userID uuid.UUID) error {
+			return s.RevokeAllSessions(m.WithUserDetails(ctx, m.UserDataContext{UserID: userID}))
+		}},
+		{"change password", func(ctx context.Context, s *service, userID uuid.UUID) error {
+			return s.ChangePassword(m.WithUserDetails(ctx, m.UserDataContext{UserID: userID}), "old-password", "new-password")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepo()
+			s := newTestService(t, repo, nil)
+			ctx := context.Background()
+
+			authn := m.NewAuthenticator(m.AuthOptions{APIKeys: NewAPIKeyVerifier(repo, memoryRoles{})})
+			handler := authn.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			status := func(key string) int {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Authorization", "ApiKey "+key)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			password, err := hashPassword("old-password", s.conf.BcryptCost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			userID := uuid.New()
+			repo.users[userID] = &user.User{ID: userID, Password: password}
+
+			const plain = apiKeyPrefix + "_0123456789abcdef_secret"
+			key := &APIKey{ID: uuid.New(), UserID: userID, Prefix: "0123456789abcdef", KeyHash: hashToken(plain)}
+			repo.apiKeys[key.ID] = key
+
+			if got := status(plain); got != http.StatusOK {
+				t.Fatalf("before: status = %d, want %d", got, http.StatusOK)
+			}
+			if err := tt.signOut(ctx, s, userID); err != nil {
+				t.Fatal(err)
+			}
+			if got := status(plain); got != http.StatusUnauthorized {
+				t.Fatalf("after: status = %d, want %d", got, http.StatusUnauthorized)
+			}
+		})
+	}
+}
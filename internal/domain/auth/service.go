@@ -2,44 +2,102 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/role"
 	"example.com/goapi/internal/domain/user"
+	"example.com/goapi/internal/jwtkeys"
+	"example.com/goapi/internal/mailer"
 	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/oidc"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Service interface {
 	Create(ctx context.Context, payload *RegisterUserPayload) (*user.User, error)
-	Login(ctx context.Context, email, password string) (*TokenPair, error)
+	Login(ctx context.Context, email, password string, device *DeviceInfo) (*LoginResult, error)
+	LoginTwoFactor(ctx context.Context, challengeToken, code string, device *DeviceInfo) (*TokenPair, error)
 	Logout(ctx context.Context, refreshToken string) error
-	RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string, device *DeviceInfo) (*TokenPair, error)
+
+	// Email verification
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+
+	// Password recovery and change
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) error
+
+	// Two-factor authentication of the authenticated user
+	SetupTwoFactor(ctx context.Context) (*TwoFactorSetup, error)
+	ConfirmTwoFactor(ctx context.Context, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, password, code string) error
+
+	// Sign in with and linking of external OpenID Connect providers
+	StartOIDC(ctx context.Context, provider string, link bool) (*OIDCAuthorization, error)
+	FinishOIDC(ctx context.Context, provider, code, state, signedState string, device *DeviceInfo) (*OIDCCallback, error)
+	ListIdentities(ctx context.Context) ([]*Identity, error)
+	UnlinkIdentity(ctx context.Context, id uuid.UUID) error
+
+	// Personal API keys of the authenticated user
+	CreateAPIKey(ctx context.Context, payload *CreateAPIKeyPayload) (*APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+
+	// Session management of the authenticated user
+	ListSessions(ctx context.Context) ([]*RefreshToken, error)
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	RevokeAllSessions(ctx context.Context) error
+
+	// SignOutUser revokes every session, access token and API key of a user (admin only)
+	SignOutUser(ctx context.Context, userID uuid.UUID) error
 }
 
 type service struct {
-	repo         Repository
-	accessToken  TokenConfig
-	refreshToken TokenConfig
+	repo        Repository
+	roles       role.Repository
+	mailer      mailer.Mailer
+	keys        *jwtkeys.KeySet
+	revocations cache.RevocationStore
+	attempts    cache.LoginAttemptStore
+	idps        map[string]*oidc.Client
+	appURL      string
+	conf        *config.ConfAuth
+
+	// Compared against when the email is unknown, so both cases take the same time
+	dummyHash []byte
 }
 
-func NewService(r Repository, accessSecret, refreshSecret string, accessExp, refreshExp time.Duration) Service {
+// NOTE: Access tokens are signed with the active key of ks, conf.SecretKey only signs one-time tokens.
+func NewService(r Repository, roles role.Repository, ml mailer.Mailer, appURL string, ks *jwtkeys.KeySet, rv cache.RevocationStore, la cache.LoginAttemptStore, idps map[string]*oidc.Client, conf *config.ConfAuth) Service {
+	dummyHash, err := hashPassword(uuid.NewString(), conf.BcryptCost)
+	if err != nil {
+		panic("Failed to generate dummy password hash: " + err.Error())
+	}
+
 	return &service{
-		repo: r,
-		accessToken: TokenConfig{
-			Secret:     accessSecret,
-			Expiration: accessExp,
-		},
-		refreshToken: TokenConfig{
-			Secret:     refreshSecret,
-			Expiration: refreshExp,
-		},
+		repo:        r,
+		roles:       roles,
+		mailer:      ml,
+		keys:        ks,
+		revocations: rv,
+		attempts:    la,
+		idps:        idps,
+		appURL:      appURL,
+		conf:        conf,
+		dummyHash:   dummyHash,
 	}
 }
 
 func (s *service) Create(ctx context.Context, payload *RegisterUserPayload) (*user.User, error) {
-	hash, err := hashPassword(payload.Password)
+	hash, err := hashPassword(payload.Password, s.conf.BcryptCost)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +108,7 @@ func (s *service) Create(ctx context.Context, payload *RegisterUserPayload) (*us
 		Username:   payload.Username,
 		Email:      payload.Email,
 		Password:   hash,
-		IsVerified: true,
+		IsVerified: false,
 	}
 
 	err = s.repo.Create(ctx, user)
@@ -58,82 +116,299 @@ func (s *service) Create(ctx context.Context, payload *RegisterUserPayload) (*us
 		return nil, errors.New(errors.ErrInternalServer, errors.PasswordHashingFailed, err)
 	}
 
+	// Every new account starts with the default role
+	if err := s.grantDefaultRole(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	// The account exists at this point, a failed email can be sent again via ResendVerification
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send verification email")
+	}
+
 	return user, nil
 }
 
-func (s *service) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+func (s *service) Login(ctx context.Context, email, password string, device *DeviceInfo) (*LoginResult, error) {
+	keys := s.loginKeys(email, device)
+	if err := s.checkLoginLockout(ctx, keys); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, err
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	// Unknown emails and wrong passwords must be indistinguishable, including the time taken
+	hash := s.dummyHash
+	if user != nil {
+		hash = user.Password
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || user == nil {
+		s.registerLoginFailure(ctx, keys)
+		return nil, errors.New(errors.ErrInvalidCredentials, errors.InvalidCredentials, nil)
 	}
 
+	s.resetLoginFailures(ctx, keys)
+
+	// NOTE: Checked after the password, so it doesn't reveal whether the account exists.
 	if !user.IsVerified {
-		return nil, errors.New(errors.ErrInvalidRequestBody, "email not verified", err)
+		return nil, errors.New(errors.ErrUserNotAuthorized, "email not verified", nil)
+	}
+
+	if user.TOTPEnabled {
+		return &LoginResult{ChallengeToken: s.issueChallengeToken(user.ID)}, nil
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
-		return nil, errors.New(errors.ErrInvalidRequestBody, "wrong password", err)
+	tokens, err := s.generateTokenPair(ctx, user.ID, newSession(device))
+	if err != nil {
+		return nil, err
 	}
 
-	return s.generateTokenPair(ctx, user.ID)
+	return &LoginResult{Tokens: tokens}, nil
 }
 
 // Logout implements Service.
+// Only the session the presented refresh token belongs to is revoked.
 func (s *service) Logout(ctx context.Context, refreshToken string) error {
 	userData, ok := m.GetUserDetailsFromContext(ctx)
 	if !ok {
 		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
 	}
 
-	// 1. Get hash token via userId
-	x, err := s.repo.GetRefreshTokenHash(ctx, userData.UserID)
+	// 1. Get the presented token via its hash
+	storedToken, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
 	if err != nil {
-		// Don't reveal whether token exists or not
 		return errors.New(errors.ErrInternalServer, "cannot get refresh token by hash", err)
 	}
 
-	// 2. Revoke the token
-	if err := s.repo.RevokeRefreshToken(ctx, x.TokenHash); err != nil {
+	// Don't reveal whether token exists or to whom it belongs
+	if storedToken == nil || storedToken.UserID != userData.UserID {
+		return errors.New(errors.ErrUnauthorized, "invalid refresh token", nil)
+	}
+
+	// 2. Revoke the session of the token
+	if _, err := s.repo.RevokeSession(ctx, userData.UserID, storedToken.SessionID); err != nil {
 		return errors.New(errors.ErrInternalServer, "failed to revoke token", err)
 	}
 
-	return nil
+	// 3. Revoke the access token used for this request
+	return s.revokeAccessToken(ctx, userData)
 }
 
 // RefreshTokens implements Service.
-// TODO: Fix Refresh Token method
-func (s *service) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
-	// // 1. Verify the refresh token exists and isn't revoked
-	// x, _ := hashPassword(refreshToken)
-	// tokenHash := string(x)
-
-	// storedToken, err := s.repo.GetRefreshTokenHash(ctx, tokenHash)
-	// if err != nil {
-	// 	return nil, errors.New(errors.ErrUnauthorized, "invalid refresh token", err)
-	// }
-
-	// // 2. Check if token is expired
-	// if time.Now().After(storedToken.ExpiresAt) {
-	// 	return nil, errors.New(errors.ErrUnauthorized, "refresh token expired", err)
-	// }
-
-	// // 3. Check if token is revoked
-	// if storedToken.Revoked {
-	// 	// Security measure: revoke all tokens for this user if a revoked token is used
-	// 	_ = s.repo.RevokeAllRefreshTokens(ctx, storedToken.UserID)
-	// 	return nil, errors.New(errors.ErrUnauthorized, "refresh token revoked", err)
-	// }
-
-	// // 4. Invalidate the current refresh token (rotation)
-	// if err := s.repo.RevokeRefreshToken(ctx, tokenHash); err != nil {
-	// 	return nil, errors.New(errors.ErrInternalServer, "failed to revoke token", err)
-	// }
-
-	// // 5. Generate new token pair
-	// newTokens, err := s.generateTokenPair(ctx, storedToken.UserID)
-	// if err != nil {
-	// 	return nil, errors.New(errors.ErrInternalServer, "failed to generate tokens", err)
-	// }
-
-	return &TokenPair{}, nil
+// Refresh tokens are single use: every call revokes the presented token and issues a new pair.
+func (s *service) RefreshTokens(ctx context.Context, refreshToken string, device *DeviceInfo) (*TokenPair, error) {
+	tokenHash := hashToken(refreshToken)
+
+	// 1. Verify the refresh token exists
+	storedToken, err := s.repo.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get refresh token by hash", err)
+	}
+
+	if storedToken == nil {
+		return nil, errors.New(errors.ErrUnauthorized, "invalid refresh token", nil)
+	}
+
+	// 2. Check if token is revoked
+	if storedToken.Revoked {
+		return nil, s.refreshTokenReused(ctx, storedToken.UserID)
+	}
+
+	// 3. Check if token is expired
+	if time.Now().After(storedToken.ExpiresAt) {
+		return nil, errors.New(errors.ErrUnauthorized, "refresh token expired", nil)
+	}
+
+	// 4. Replace the current refresh token with a new one (rotation). Losing the race against a
+	// concurrent refresh with the same token is reuse as well. The new token is stored along with
+	// the revoke, so the family revoke of the loser covers the token of the winner.
+	refreshToken, next, err := s.newRefreshToken(storedToken.UserID, continueSession(storedToken, device))
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "failed to generate tokens", err)
+	}
+
+	rotated, err := s.repo.RotateRefreshToken(ctx, tokenHash, next)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "failed to revoke token", err)
+	}
+
+	if !rotated {
+		return nil, s.refreshTokenReused(ctx, storedToken.UserID)
+	}
+
+	// 5. Generate the access token of the new pair
+	accessToken, err := s.generateAccessToken(ctx, storedToken.UserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "failed to generate tokens", err)
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// refreshTokenReused handles a revoked refresh token being presented again.
+// Security measure: it means the token has leaked, so the whole token family of the user is revoked.
+func (s *service) refreshTokenReused(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return errors.New(errors.ErrInternalServer, "failed to revoke tokens", err)
+	}
+
+	return errors.New(errors.ErrUnauthorized, "refresh token revoked", nil)
+}
+
+// VerifyEmail implements Service.
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.consumeOneTimeToken(ctx, token, PurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MarkVerified(ctx, userID); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return nil
+}
+
+// ResendVerification implements Service.
+// NOTE: Unknown and already verified emails are ignored silently so this can't be used to find accounts.
+func (s *service) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil || user.IsVerified {
+		return nil
+	}
+
+	// Only the latest verification email stays valid
+	if err := s.repo.InvalidateVerificationTokens(ctx, user.ID, PurposeEmailVerification, time.Now()); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return errors.New(errors.ErrExternalAPIFailure, "failed to send verification email", err)
+	}
+
+	return nil
+}
+
+// ForgotPassword implements Service.
+// NOTE: Unknown emails are ignored silently so this can't be used to find accounts.
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	// Only the latest reset email stays valid
+	if err := s.repo.InvalidateVerificationTokens(ctx, user.ID, PurposePasswordReset, time.Now()); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	if err := s.sendPasswordResetEmail(ctx, user); err != nil {
+		return errors.New(errors.ErrExternalAPIFailure, "failed to send password reset email", err)
+	}
+
+	return nil
+}
+
+// ResetPassword implements Service.
+func (s *service) ResetPassword(ctx context.Context, token, password string) error {
+	userID, err := s.consumeOneTimeToken(ctx, token, PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	return s.setPassword(ctx, userID, password)
+}
+
+// ChangePassword implements Service.
+func (s *service) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	user, err := s.repo.GetByID(ctx, userData.UserID)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil {
+		return errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userData.UserID), nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(currentPassword)); err != nil {
+		return errors.New(errors.ErrPasswordMismatch, "current password is wrong", nil)
+	}
+
+	// The user cutoff set by setPassword covers the current token as well
+	return s.setPassword(ctx, user.ID, newPassword)
+}
+
+// ListSessions implements Service.
+func (s *service) ListSessions(ctx context.Context) ([]*RefreshToken, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	sessions, err := s.repo.ListActiveSessions(ctx, userData.UserID, time.Now())
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession implements Service.
+func (s *service) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	revoked, err := s.repo.RevokeSession(ctx, userData.UserID, sessionID)
+	if err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	if !revoked {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, sessionID), nil)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions implements Service.
+func (s *service) RevokeAllSessions(ctx context.Context) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	return s.signOutEverywhere(ctx, userData.UserID)
+}
+
+// SignOutUser implements Service.
+func (s *service) SignOutUser(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil {
+		return errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userID), nil)
+	}
+
+	return s.signOutEverywhere(ctx, userID)
 }
@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/totp"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Challenge tokens are signed like one-time tokens, the payload is
+// userID (16 bytes) | expiry as unix seconds (8 bytes).
+// NOTE: They are not stored, the TOTP step check and single-use recovery codes prevent replays.
+const challengePayloadSize = 16 + 8
+
+// SetupTwoFactor implements Service.
+// Enrollment is pending until ConfirmTwoFactor receives a valid code for the secret.
+func (s *service) SetupTwoFactor(ctx context.Context) (*TwoFactorSetup, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New(errors.ErrInvalidRequestBody, "two-factor authentication is already enabled", nil)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.New(errors.ErrTokenGeneration, "cannot generate secret", err)
+	}
+
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "cannot encrypt secret", err)
+	}
+
+	if err := s.repo.SetTOTPSecret(ctx, user.ID, encrypted); err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(s.conf.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor implements Service.
+// Returns the recovery codes, they are only shown once.
+func (s *service) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New(errors.ErrInvalidRequestBody, "two-factor authentication is already enabled", nil)
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, errors.New(errors.ErrInvalidRequestBody, "two-factor setup was not started", nil)
+	}
+
+	secret, err := s.decryptSecret(*user.TOTPSecret)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternalServer, "cannot decrypt secret", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, errors.New(errors.ErrInvalidRequestBody, "invalid two-factor code", nil)
+	}
+
+	codes, records, err := generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, errors.New(errors.ErrTokenGeneration, "cannot generate recovery codes", err)
+	}
+
+	if err := s.repo.EnableTOTP(ctx, user.ID, step, records); err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor implements Service.
+// Requires the password and a current code, a stolen access token alone is not enough.
+func (s *service) DisableTwoFactor(ctx context.Context, password, code string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return errors.New(errors.ErrInvalidRequestBody, "two-factor authentication is not enabled", nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
+		return errors.New(errors.ErrPasswordMismatch, "password is wrong", nil)
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errors.New(errors.ErrInvalidRequestBody, "invalid two-factor code", nil)
+	}
+
+	if err := s.repo.DisableTOTP(ctx, user.ID); err != nil {
+		return errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return nil
+}
+
+// LoginTwoFactor implements Service.
+func (s *service) LoginTwoFactor(ctx context.Context, challengeToken, code string, device *DeviceInfo) (*TokenPair, error) {
+	userID, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guesses are throttled per user, the challenge token itself can be reused until it expires
+	keys := []loginKey{{key: "2fa:" + userID.String(), maxAttempts: s.conf.LoginMaxAttempts}}
+	if device != nil && device.IPAddress != "" {
+		keys = append(keys, loginKey{key: "ip:" + device.IPAddress, maxAttempts: s.conf.LoginMaxAttemptsIP})
+	}
+
+	if err := s.checkLoginLockout(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil || !user.TOTPEnabled {
+		return nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		s.registerLoginFailure(ctx, keys)
+		return nil, errors.New(errors.ErrInvalidCredentials, "invalid two-factor code", nil)
+	}
+
+	s.resetLoginFailures(ctx, keys)
+
+	return s.generateTokenPair(ctx, user.ID, newSession(device))
+}
+
+// Private helper methods
+func (s *service) currentUser(ctx context.Context) (*user.User, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	user, err := s.repo.GetByID(ctx, userData.UserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if user == nil {
+		return nil, errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userData.UserID), nil)
+	}
+
+	return user, nil
+}
+
+// Private helper methods
+// Accepts either a TOTP code or an unused recovery code.
+func (s *service) verifySecondFactor(ctx context.Context, user *user.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		if user.TOTPSecret == nil {
+			return false, nil
+		}
+
+		secret, err := s.decryptSecret(*user.TOTPSecret)
+		if err != nil {
+			return false, errors.New(errors.ErrInternalServer, "cannot decrypt secret", err)
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+
+		// A code is accepted only once, even within its period
+		used, err := s.repo.UseTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return false, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+		}
+
+		return used, nil
+	}
+
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return false, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return consumed, nil
+}
+
+// Private helper methods
+func (s *service) issueChallengeToken(userID uuid.UUID) string {
+	payload := make([]byte, challengePayloadSize)
+	copy(payload[:16], userID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(time.Now().Add(twoFactorChallengeTTL).Unix()))
+
+	return signPayload(s.conf.SecretKey, purposeTwoFactorChallenge, payload)
+}
+
+// Private helper methods
+func (s *service) parseChallengeToken(token string) (uuid.UUID, error) {
+	payload, ok := verifyPayload(s.conf.SecretKey, purposeTwoFactorChallenge, token)
+	if !ok || len(payload) != challengePayloadSize {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if time.Now().After(expiresAt) {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenExpired, "token expired", nil)
+	}
+
+	userID, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, errors.New(errors.ErrAuthTokenInvalid, "invalid token", nil)
+	}
+
+	return userID, nil
+}
+
+// Private helper methods
+// TOTP secrets are encrypted with AES-GCM using a key derived from the auth secret,
+// stored as base64(nonce | ciphertext).
+func (s *service) encryptSecret(plain string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Private helper methods
+func (s *service) decryptSecret(encoded string) (string, error) {
+	gcm, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted secret too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func (s *service) secretCipher() (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, []byte(s.conf.SecretKey))
+	mac.Write([]byte("totp-secret-encryption"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// generateRecoveryCodes returns the codes shown to the user and the records storing their hashes
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []*RecoveryCode, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]*RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		// 8 characters, shown as xxxx-xxxx
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		records[i] = &RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: hashToken(raw),
+		}
+	}
+
+	return codes, records, nil
+}
+
+// normalizeRecoveryCode makes the dash and case of a recovery code optional
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
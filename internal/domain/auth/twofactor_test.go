@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/user"
+	"example.com/goapi/pkg/totp"
+	"github.com/google/uuid"
+)
+
+// enrollTwoFactor stores a user with two-factor authentication enabled and returns the secret
+func enrollTwoFactor(t *testing.T, s *service, repo *memoryRepo) (uuid.UUID, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := &user.User{ID: uuid.New(), Email: "jane@example.com", TOTPSecret: &encrypted, TOTPEnabled: true}
+	repo.users[u.ID] = u
+	return u.ID, secret
+}
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func assertInvalidCode(t *testing.T, err error) {
+	t.Helper()
+
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != errors.ErrInvalidCredentials {
+		t.Fatalf("err = %v, want %s", err, errors.ErrInvalidCredentials)
+	}
+}
+
+// The steps are chosen so the results hold if the clock crosses into the next step during a test
+func TestLoginTwoFactorSkew(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	userID, secret := enrollTwoFactor(t, s, repo)
+	challenge := s.issueChallengeToken(userID)
+	current := totp.Step(time.Now())
+
+	for _, step := range []int64{current - 3, current + 3} {
+		_, err := s.LoginTwoFactor(context.Background(), challenge, totpCode(t, secret, step), nil)
+		assertInvalidCode(t, err)
+	}
+
+	if _, err := s.LoginTwoFactor(context.Background(), challenge, totpCode(t, secret, current+1), nil); err != nil {
+		t.Fatalf("code of the next step: %v", err)
+	}
+}
+
+func TestLoginTwoFactorRejectsReplay(t *testing.T) {
+	repo := newMemoryRepo()
+	s := newTestService(t, repo, nil)
+	userID, secret := enrollTwoFactor(t, s, repo)
+	challenge := s.issueChallengeToken(userID)
+	current := totp.Step(time.Now())
+
+	code := totpCode(t, secret, current)
+	if _, err := s.LoginTwoFactor(context.Background(), challenge, code, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.LoginTwoFactor(context.Background(), challenge, code, nil)
+	assertInvalidCode(t, err)
+
+	// A code of an earlier step is rejected once a later one was used, even within the window
+	if _, err := s.LoginTwoFactor(context.Background(), challenge, totpCode(t, secret, current+1), nil); err != nil {
+		t.Fatalf("code of the next step: %v", err)
+	}
+
+	_, err = s.LoginTwoFactor(context.Background(), challenge, code, nil)
+	assertInvalidCode(t, err)
+}
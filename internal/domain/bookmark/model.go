@@ -0,0 +1,70 @@
+package bookmark
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bookmark represents the database model for a post saved by a user
+type Bookmark struct {
+	UserID       uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	PostID       uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CollectionID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt    time.Time
+}
+
+// Collection represents the database model for a named group of bookmarks
+type Collection struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	Name      string    `gorm:"size:100;not null"`
+	CreatedAt time.Time
+	// Read only, number of bookmarks of posts that aren't deleted
+	BookmarkCount int64 `gorm:"->;-:migration"`
+}
+
+// TableName overrides the table name used by Collection
+func (Collection) TableName() string {
+	return "bookmark_collections"
+}
+
+// Collections represents a collection of bookmark collections
+type Collections []*Collection
+
+// CollectionDTO represents the data transfer object for a Collection
+type CollectionDTO struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	BookmarkCount int64  `json:"bookmark_count"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// Form represents the input structure for bookmarking a post, the body is optional
+type Form struct {
+	CollectionID *uuid.UUID `json:"collection_id"`
+}
+
+// CollectionForm represents the input structure for creating a Collection
+type CollectionForm struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// ToDto converts a Collection model into a DTO
+func (c *Collection) ToDto() *CollectionDTO {
+	return &CollectionDTO{
+		ID:            c.ID.String(),
+		Name:          c.Name,
+		BookmarkCount: c.BookmarkCount,
+		CreatedAt:     c.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// ToDto converts a collection of Collection models into a slice of DTOs
+func (items Collections) ToDto() []*CollectionDTO {
+	dtos := make([]*CollectionDTO, len(items))
+	for i, v := range items {
+		dtos[i] = v.ToDto()
+	}
+	return dtos
+}
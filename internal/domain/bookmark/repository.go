@@ -0,0 +1,28 @@
+package bookmark
+
+import (
+	"context"
+
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/post"
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Bookmarks.
+type Repository interface {
+	// Set bookmarks the post, bookmarking it again moves it to the given collection if any
+	Set(ctx context.Context, b *Bookmark) error
+	Delete(ctx context.Context, userID, postID uuid.UUID) error
+	// List returns the bookmarked posts, optionally of one collection, deleted posts are hidden
+	List(ctx context.Context, userID uuid.UUID, collectionID *uuid.UUID, fq *query.QueryParams) (post.Posts, error)
+	LoadReactions(ctx context.Context, posts post.Posts, viewerID uuid.UUID) error
+	LoadAttachments(ctx context.Context, posts post.Posts) error
+
+	// Collection methods
+	CreateCollection(ctx context.Context, c *Collection) error
+	GetCollection(ctx context.Context, userID, id uuid.UUID) (*Collection, error)
+	CollectionNameExists(ctx context.Context, userID uuid.UUID, name string) (bool, error)
+	ListCollections(ctx context.Context, userID uuid.UUID) (Collections, error)
+	// DeleteCollection keeps the bookmarks of the collection
+	DeleteCollection(ctx context.Context, userID, id uuid.UUID) (bool, error)
+}
@@ -0,0 +1,181 @@
+package bookmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// This is what the business layer of Bookmarks is capable off
+type Service interface {
+	Bookmark(ctx context.Context, postID uuid.UUID, input *Form) error
+	RemoveBookmark(ctx context.Context, postID uuid.UUID) error
+	List(ctx context.Context, collectionID *uuid.UUID, fq *query.QueryParams) (post.Posts, error)
+
+	CreateCollection(ctx context.Context, input *CollectionForm) (*Collection, error)
+	ListCollections(ctx context.Context) (Collections, error)
+	DeleteCollection(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	repo  Repository
+	posts post.Repository
+}
+
+func NewService(r Repository, posts post.Repository) Service {
+	return &service{repo: r, posts: posts}
+}
+
+func (s *service) Bookmark(ctx context.Context, postID uuid.UUID, input *Form) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if _, err := post.FindVisible(ctx, s.posts, postID); err != nil {
+		return err
+	}
+
+	if input.CollectionID != nil {
+		if _, err := s.collection(ctx, userData.UserID, *input.CollectionID); err != nil {
+			return err
+		}
+	}
+
+	err := s.repo.Set(ctx, &Bookmark{
+		UserID:       userData.UserID,
+		PostID:       postID,
+		CollectionID: input.CollectionID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return nil
+}
+
+// RemoveBookmark deletes the bookmark of the caller, removing it twice is a no-op
+func (s *service) RemoveBookmark(ctx context.Context, postID uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if err := s.repo.Delete(ctx, userData.UserID, postID); err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	return nil
+}
+
+func (s *service) List(ctx context.Context, collectionID *uuid.UUID, fq *query.QueryParams) (post.Posts, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if collectionID != nil {
+		if _, err := s.collection(ctx, userData.UserID, *collectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	posts, err := s.repo.List(ctx, userData.UserID, collectionID, fq)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if err := s.repo.LoadReactions(ctx, posts, userData.UserID); err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if err := s.repo.LoadAttachments(ctx, posts); err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return posts, nil
+}
+
+func (s *service) CreateCollection(ctx context.Context, input *CollectionForm) (*Collection, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	exists, err := s.repo.CollectionNameExists(ctx, userData.UserID, input.Name)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if exists {
+		return nil, errors.New(errors.ErrDBDuplicateEntry, fmt.Sprintf("collection '%s' already exists", input.Name), nil)
+	}
+
+	c := &Collection{
+		ID:        uuid.New(),
+		UserID:    userData.UserID,
+		Name:      input.Name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateCollection(ctx, c); err != nil {
+		return nil, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return c, nil
+}
+
+func (s *service) ListCollections(ctx context.Context) (Collections, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	collections, err := s.repo.ListCollections(ctx, userData.UserID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return collections, nil
+}
+
+// DeleteCollection removes the collection, its bookmarks are kept without collection
+func (s *service) DeleteCollection(ctx context.Context, id uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	deleted, err := s.repo.DeleteCollection(ctx, userData.UserID, id)
+	if err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if !deleted {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return nil
+}
+
+// Private helper methods
+// Collections of other users are reported as not found.
+func (s *service) collection(ctx context.Context, userID, id uuid.UUID) (*Collection, error) {
+	c, err := s.repo.GetCollection(ctx, userID, id)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if c == nil {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return c, nil
+}
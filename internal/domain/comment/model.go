@@ -0,0 +1,131 @@
+package comment
+
+import (
+	"time"
+
+	"example.com/goapi/internal/domain/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Comment represents the database model for a comment on a post
+type Comment struct {
+	ID        uuid.UUID  `gorm:"primarykey"`
+	PostID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index"`
+	User      *user.User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	ParentID  *uuid.UUID `gorm:"type:uuid;index"`
+	Replies   Comments   `gorm:"-"`
+	Content   string     `gorm:"type:text;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Comments represents a collection of comments
+type Comments []*Comment
+
+// DTO represents the data transfer object for a Comment.
+// Deleted comments keep their place in the thread without content and author.
+type DTO struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"post_id"`
+	ParentID  *string   `json:"parent_id"`
+	Content   string    `json:"content"`
+	User      *user.DTO `json:"user,omitempty"`
+	Deleted   bool      `json:"deleted"`
+	Replies   []*DTO    `json:"replies"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// Form represents the input structure for creating or updating a Comment.
+// ParentID is only used when creating a reply.
+type Form struct {
+	Content  string     `json:"content" validate:"required,max=5000"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// ToModel converts a Form into a Comment model
+func (f *Form) ToModel() *Comment {
+	return &Comment{
+		ID:       uuid.New(),
+		Content:  f.Content,
+		ParentID: f.ParentID,
+	}
+}
+
+// IsDeleted reports whether the comment was soft deleted
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt.Valid
+}
+
+// ToDto converts a Comment model and its replies into a DTO
+func (c *Comment) ToDto() *DTO {
+	dto := &DTO{
+		ID:        c.ID.String(),
+		PostID:    c.PostID.String(),
+		Deleted:   c.IsDeleted(),
+		Replies:   c.Replies.ToDto(),
+		CreatedAt: c.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt: c.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	if c.ParentID != nil {
+		parentID := c.ParentID.String()
+		dto.ParentID = &parentID
+	}
+
+	if !dto.Deleted {
+		dto.Content = c.Content
+		if c.UserID != nil && c.User != nil && c.User.ID != uuid.Nil {
+			dto.User = c.User.ToDto()
+		}
+	}
+
+	return dto
+}
+
+// ToDto converts a collection of Comment models into a slice of DTOs
+func (items Comments) ToDto() []*DTO {
+	dtos := make([]*DTO, len(items))
+	for i, v := range items {
+		dtos[i] = v.ToDto()
+	}
+	return dtos
+}
+
+// Thread nests the comments of a post under their parents and returns the top level comments.
+// Deleted comments are dropped unless a reply still hangs below them.
+func (items Comments) Thread() Comments {
+	byID := make(map[uuid.UUID]*Comment, len(items))
+	for _, c := range items {
+		c.Replies = Comments{}
+		byID[c.ID] = c
+	}
+
+	roots := Comments{}
+	for _, c := range items {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+
+	return roots.prune()
+}
+
+func (items Comments) prune() Comments {
+	kept := Comments{}
+	for _, c := range items {
+		c.Replies = c.Replies.prune()
+		if c.IsDeleted() && len(c.Replies) == 0 {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
@@ -0,0 +1,24 @@
+package comment
+
+import (
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+)
+
+// Ownership policy of comments.
+// Only the author can edit a comment, users who may delete any post (admins) can also delete comments.
+
+// CanUpdate reports whether the user may edit the comment
+func CanUpdate(userData m.UserDataContext, c *Comment) bool {
+	return c.IsOwnedBy(userData)
+}
+
+// CanDelete reports whether the user may delete the comment
+func CanDelete(userData m.UserDataContext, c *Comment) bool {
+	return c.IsOwnedBy(userData) || userData.HasPermission(role.PostsDeleteAny)
+}
+
+// IsOwnedBy reports whether the comment was written by the user
+func (c *Comment) IsOwnedBy(userData m.UserDataContext) bool {
+	return c.UserID != nil && *c.UserID == userData.UserID
+}
@@ -0,0 +1,17 @@
+package comment
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Comments.
+type Repository interface {
+	Create(ctx context.Context, c *Comment) error
+	FindById(ctx context.Context, id uuid.UUID) (*Comment, error)
+	// ListByPost returns all comments of a post in creation order, including deleted ones
+	ListByPost(ctx context.Context, postID uuid.UUID) (Comments, error)
+	Update(ctx context.Context, c *Comment) error
+	DeleteById(ctx context.Context, id uuid.UUID) (bool, error)
+}
@@ -0,0 +1,131 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// This is what the business layer of Comments is capable off
+type Service interface {
+	List(ctx context.Context, postID uuid.UUID) (Comments, error)
+	Create(ctx context.Context, postID uuid.UUID, input *Form) (*Comment, error)
+	Update(ctx context.Context, id uuid.UUID, input *Form) (*Comment, error)
+	DeleteById(ctx context.Context, id uuid.UUID) error
+}
+
+type service struct {
+	repo  Repository
+	posts post.Repository
+}
+
+func NewService(r Repository, posts post.Repository) Service {
+	return &service{repo: r, posts: posts}
+}
+
+// List returns the comments of a post as threads
+func (s *service) List(ctx context.Context, postID uuid.UUID) (Comments, error) {
+	// Comments of unpublished posts are as hidden as the posts
+	if _, err := post.FindVisible(ctx, s.posts, postID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.ListByPost(ctx, postID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return comments.Thread(), nil
+}
+
+func (s *service) Create(ctx context.Context, postID uuid.UUID, input *Form) (*Comment, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if _, err := post.FindVisible(ctx, s.posts, postID); err != nil {
+		return nil, err
+	}
+
+	// Replies must stay within the thread of the same post
+	if input.ParentID != nil {
+		parent, err := s.repo.FindById(ctx, *input.ParentID)
+		if err != nil {
+			return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+		}
+
+		if parent == nil || parent.PostID != postID {
+			return nil, errors.New(errors.ErrInvalidRequestBody, "parent comment not found on this post", nil)
+		}
+	}
+
+	c := input.ToModel()
+	c.PostID = postID
+	c.UserID = &userData.UserID
+
+	if err := s.repo.Create(ctx, c); err != nil {
+		return nil, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	return c, nil
+}
+
+func (s *service) Update(ctx context.Context, id uuid.UUID, input *Form) (*Comment, error) {
+	c, err := s.authorize(ctx, id, CanUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Content = input.Content
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return c, nil
+}
+
+// DeleteById soft deletes the comment, its replies stay visible
+func (s *service) DeleteById(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.authorize(ctx, id, CanDelete); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteById(ctx, id)
+	if err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if !deleted {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return nil
+}
+
+// authorize loads the comment and checks the caller against the given policy
+func (s *service) authorize(ctx context.Context, id uuid.UUID, policy func(m.UserDataContext, *Comment) bool) (*Comment, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	c, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if c == nil {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	if !policy(userData, c) {
+		return nil, errors.New(errors.ErrUserNotAuthorized, "you are not allowed to modify this comment", nil)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package comment
+
+import (
+	"context"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+type memoryPosts struct {
+	post.Repository
+	post *post.Post
+}
+
+func (r memoryPosts) FindById(_ context.Context, id uuid.UUID) (*post.Post, error) {
+	if id != r.post.ID {
+		return nil, errors.New(errors.ErrDBNoRows, "not found", nil)
+	}
+	return r.post, nil
+}
+
+type memoryComments struct {
+	Repository
+	created Comments
+}
+
+func (r *memoryComments) ListByPost(context.Context, uuid.UUID) (Comments, error) {
+	return r.created, nil
+}
+
+func (r *memoryComments) Create(_ context.Context, c *Comment) error {
+	r.created = append(r.created, c)
+	return nil
+}
+
+func TestCommentsOfUnpublishedPostsAreHidden(t *testing.T) {
+	owner := uuid.New()
+	draft := &post.Post{ID: uuid.New(), UserID: &owner, Status: post.StatusDraft}
+	comments := &memoryComments{}
+	s := NewService(comments, memoryPosts{post: draft})
+
+	assertNotFound := func(t *testing.T, err error) {
+		t.Helper()
+		apiErr, ok := err.(*errors.ApiError)
+		if !ok || apiErr.Code != errors.ErrDBNoRows {
+			t.Fatalf("err = %v, want %s", err, errors.ErrDBNoRows)
+		}
+	}
+
+	t.Run("list anonymously", func(t *testing.T) {
+		_, err := s.List(context.Background(), draft.ID)
+		assertNotFound(t, err)
+	})
+
+	t.Run("comment as other user", func(t *testing.T) {
+		ctx := m.WithUserDetails(context.Background(), m.UserDataContext{UserID: uuid.New()})
+		_, err := s.Create(ctx, draft.ID, &Form{Content: "first"})
+		assertNotFound(t, err)
+
+		if len(comments.created) != 0 {
+			t.Fatal("comment was created")
+		}
+	})
+}
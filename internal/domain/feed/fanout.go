@@ -0,0 +1,174 @@
+package feed
+
+import (
+	"context"
+	"sync"
+
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/post"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Followers are loaded and pushed to in batches of this size
+const fanOutBatchSize = 500
+
+// FanOut pushes new posts into the cached timelines of the author and their followers
+// (fan-out on write). Posts of authors with FanOutMaxFollowers or more followers are
+// skipped, the service merges them into the timelines on read instead.
+// NOTE: Stop drains the queue on shutdown, posts still queued when it gives up are lost and
+// the timelines catch up when they expire.
+type FanOut struct {
+	repo  Repository
+	store cache.TimelineStore
+	conf  *config.ConfFeed
+	jobs  chan fanOutJob
+
+	// Guards jobs against sends after Stop closed it
+	mu      sync.RWMutex
+	stopped bool
+	workers sync.WaitGroup
+}
+
+type fanOutJob struct {
+	post   *post.Post
+	logger *zerolog.Logger
+}
+
+var _ post.Publisher = (*FanOut)(nil)
+
+// NewFanOut starts the workers of the fan-out
+func NewFanOut(r Repository, store cache.TimelineStore, conf *config.ConfFeed) *FanOut {
+	f := &FanOut{
+		repo:  r,
+		store: store,
+		conf:  conf,
+		jobs:  make(chan fanOutJob, conf.FanOutQueueSize),
+	}
+
+	for range conf.FanOutWorkers {
+		f.workers.Add(1)
+		go f.work()
+	}
+
+	return f
+}
+
+// Stop lets the workers push the queued posts and waits for them until the context is done.
+// Posts published afterwards are pushed within the request.
+func (f *FanOut) Stop(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.stopped {
+		f.stopped = true
+		close(f.jobs)
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PostPublished implements post.Publisher.
+// When the queue is full the post is pushed within the request, slowing down writers
+// rather than losing the post.
+func (f *FanOut) PostPublished(ctx context.Context, p *post.Post) {
+	if p.UserID == nil {
+		return
+	}
+
+	job := fanOutJob{post: p, logger: zerolog.Ctx(ctx)}
+	if !f.enqueue(job) {
+		job.logger.Warn().Str("post_id", p.ID.String()).Msg("Fan-out queue full or stopped, pushing post synchronously")
+		f.run(ctx, job)
+	}
+}
+
+// enqueue hands the job to the workers, false when the queue is full or stopped
+func (f *FanOut) enqueue(job fanOutJob) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.stopped {
+		return false
+	}
+
+	select {
+	case f.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *FanOut) work() {
+	defer f.workers.Done()
+
+	for job := range f.jobs {
+		f.run(job.logger.WithContext(context.Background()), job)
+	}
+}
+
+func (f *FanOut) run(ctx context.Context, job fanOutJob) {
+	if err := f.fanOut(ctx, job.post); err != nil {
+		job.logger.Error().Err(err).Str("post_id", job.post.ID.String()).Msg("Failed to push post into timelines")
+	}
+}
+
+func (f *FanOut) fanOut(ctx context.Context, p *post.Post) error {
+	authorID := *p.UserID
+	entry := cache.TimelineEntry{PostID: p.ID.String(), PublishedAt: p.CreatedAt}
+	if p.PublishAt != nil {
+		entry.PublishedAt = *p.PublishAt
+	}
+
+	// Authors always see their own posts
+	if err := f.store.PushTimelines(ctx, []string{authorID.String()}, entry, f.conf.TimelineSize); err != nil {
+		return err
+	}
+
+	count, err := f.repo.CountFollowers(ctx, authorID)
+	if err != nil {
+		return err
+	}
+
+	if count >= f.conf.FanOutMaxFollowers {
+		return nil
+	}
+
+	after := uuid.Nil
+	for {
+		ids, err := f.repo.FollowerIDs(ctx, authorID, after, fanOutBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		owners := make([]string, len(ids))
+		for i, id := range ids {
+			owners[i] = id.String()
+		}
+
+		if err := f.store.PushTimelines(ctx, owners, entry, f.conf.TimelineSize); err != nil {
+			return err
+		}
+
+		if len(ids) < fanOutBatchSize {
+			return nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/post"
+	"github.com/google/uuid"
+)
+
+// followless is an author without followers
+type followless struct {
+	Repository
+}
+
+func (followless) CountFollowers(context.Context, uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (followless) FollowerIDs(context.Context, uuid.UUID, uuid.UUID, int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func TestFanOutStopDrainsQueue(t *testing.T) {
+	author := uuid.New()
+	store := &memoryTimelines{timelines: map[string]*cache.Timeline{author.String(): {}}}
+	conf := &config.ConfFeed{FanOutWorkers: 1, FanOutQueueSize: 10, FanOutMaxFollowers: 100, TimelineSize: 100}
+	f := NewFanOut(followless{}, store, conf)
+
+	publish := func() {
+		f.PostPublished(context.Background(), &post.Post{ID: uuid.New(), UserID: &author, CreatedAt: time.Now()})
+	}
+
+	for range 5 {
+		publish()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := f.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if got := len(store.timelines[author.String()].Entries); got != 5 {
+		t.Fatalf("queued posts pushed: got %d, want 5", got)
+	}
+
+	// Published after Stop, pushed within the request
+	publish()
+	if got := len(store.timelines[author.String()].Entries); got != 6 {
+		t.Fatalf("post published after Stop: got %d entries, want 6", got)
+	}
+
+	if err := f.Stop(ctx); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+}
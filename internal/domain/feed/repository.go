@@ -2,12 +2,37 @@ package feed
 
 import (
 	"context"
+	"time"
 
 	"example.com/goapi/internal/common/query"
 	"example.com/goapi/internal/domain/post"
 	"github.com/google/uuid"
 )
 
+// Entry is a post reference of a timeline
+type Entry struct {
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
 type Repository interface {
+	// List returns the posts written by the user or by the users they follow
 	List(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (post.Posts, error)
+	// FindByIDs loads the given posts, deleted posts are left out
+	FindByIDs(ctx context.Context, ids []uuid.UUID) (post.Posts, error)
+	// LoadReactions sets the reaction counts and the reaction of the viewer
+	LoadReactions(ctx context.Context, posts post.Posts, viewerID uuid.UUID) error
+	// LoadAttachments sets the uploads attached to the posts
+	LoadAttachments(ctx context.Context, posts post.Posts) error
+
+	// Timeline methods
+	CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error)
+	// FollowerIDs returns a page of followers ordered by ID, starting after the given ID
+	FollowerIDs(ctx context.Context, userID, after uuid.UUID, limit int) ([]uuid.UUID, error)
+	// FollowedAuthors returns the users followed by userID who have at least minFollowers followers
+	FollowedAuthors(ctx context.Context, userID uuid.UUID, minFollowers int64) ([]uuid.UUID, error)
+	// ListEntries returns the newest posts of the feed of userID, leaving out the excluded authors
+	ListEntries(ctx context.Context, userID uuid.UUID, exclude []uuid.UUID, limit int) ([]Entry, error)
+	// ListEntriesByAuthors returns the newest posts written by the authors
+	ListEntriesByAuthors(ctx context.Context, authors []uuid.UUID, limit int) ([]Entry, error)
 }
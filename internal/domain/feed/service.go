@@ -2,10 +2,16 @@ package feed
 
 import (
 	"context"
+	"slices"
 
+	"example.com/goapi/internal/common/errors"
 	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
 	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 )
 
 type Service interface {
@@ -13,14 +19,194 @@ type Service interface {
 }
 
 type service struct {
-	repo Repository
+	repo  Repository
+	store cache.TimelineStore
+	conf  *config.ConfFeed
 }
 
-func NewService(r Repository) Service {
-	return &service{repo: r}
+// NewService creates the feed service, without a timeline store every feed is read from SQL
+func NewService(r Repository, store cache.TimelineStore, conf *config.ConfFeed) Service {
+	return &service{repo: r, store: store, conf: conf}
 }
 
+// List returns the posts of the caller and of the users they follow.
+// The newest pages come from the cached timeline, filtered or older pages and cache misses from SQL.
 func (s *service) List(ctx context.Context, fq *query.QueryParams) (post.Posts, error) {
-	uID := uuid.MustParse(ctx.Value("user_id").(string))
-	return s.repo.List(ctx, uID, fq)
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	posts, err := s.list(ctx, userData.UserID, fq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.LoadReactions(ctx, posts, userData.UserID); err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if err := s.repo.LoadAttachments(ctx, posts); err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return posts, nil
+}
+
+// Private helper methods
+func (s *service) list(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (post.Posts, error) {
+	if s.store != nil && s.fromTimeline(fq) {
+		posts, ok, err := s.listFromTimeline(ctx, userID, fq)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to read timeline, falling back to SQL")
+		} else if ok {
+			return posts, nil
+		}
+	}
+
+	return s.repo.List(ctx, userID, fq)
+}
+
+// Private helper methods
+// Timelines only hold the newest posts in publishing order, without filters.
+func (s *service) fromTimeline(fq *query.QueryParams) bool {
+	return fq.Sort == "desc" &&
+		len(fq.Tags) == 0 &&
+		fq.Search == "" &&
+		fq.Since == "" &&
+		fq.Until == "" &&
+		fq.Offset+fq.Limit <= s.conf.TimelineSize
+}
+
+// Private helper methods
+// Returns false when the page can't be served from the timeline.
+// Deleted and unpublished posts are dropped by hydrate, so one more page of entries is read to
+// keep the page full. If even that isn't enough, the page is read from SQL.
+func (s *service) listFromTimeline(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (post.Posts, bool, error) {
+	want := fq.Offset + fq.Limit
+	fetch := want + fq.Limit
+
+	timeline, err := s.store.ReadTimeline(ctx, userID.String(), fetch)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if timeline == nil {
+		// Build it for the next requests, this one is served from SQL
+		return nil, false, s.rebuildTimeline(ctx, userID)
+	}
+
+	if len(timeline.Entries) < want && !timeline.Complete {
+		return nil, false, nil
+	}
+
+	entries := make([]Entry, 0, len(timeline.Entries))
+	for _, e := range timeline.Entries {
+		id, err := uuid.Parse(e.PostID)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{PostID: id, CreatedAt: e.PublishedAt})
+	}
+
+	// Authors with many followers aren't pushed into timelines (fan-out on read)
+	authors, err := s.repo.FollowedAuthors(ctx, userID, s.conf.FanOutMaxFollowers)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(authors) > 0 {
+		pulled, err := s.repo.ListEntriesByAuthors(ctx, authors, fetch)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = mergeEntries(entries, pulled)
+	}
+
+	// No entries beyond the ones read, a short page is the end of the feed
+	exhausted := timeline.Complete && len(entries) < fetch
+
+	posts, err := s.hydrate(ctx, entries[:min(fetch, len(entries))])
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(posts) < want && !exhausted {
+		return nil, false, nil
+	}
+
+	if fq.Offset >= len(posts) {
+		return post.Posts{}, true, nil
+	}
+
+	return posts[fq.Offset:min(want, len(posts))], true, nil
+}
+
+// Private helper methods
+func (s *service) rebuildTimeline(ctx context.Context, userID uuid.UUID) error {
+	authors, err := s.repo.FollowedAuthors(ctx, userID, s.conf.FanOutMaxFollowers)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.repo.ListEntries(ctx, userID, authors, s.conf.TimelineSize)
+	if err != nil {
+		return err
+	}
+
+	cached := make([]cache.TimelineEntry, len(entries))
+	for i, e := range entries {
+		cached[i] = cache.TimelineEntry{PostID: e.PostID.String(), PublishedAt: e.CreatedAt}
+	}
+
+	complete := len(entries) < s.conf.TimelineSize
+	// Merged, posts pushed by the fan-out while the entries were read aren't lost
+	return s.store.MergeTimeline(ctx, userID.String(), cached, complete, s.conf.TimelineSize, s.conf.TimelineTTL)
+}
+
+// Private helper methods
+// Loads the posts in one query and keeps the order of the timeline, deleted posts are skipped.
+func (s *service) hydrate(ctx context.Context, entries []Entry) (post.Posts, error) {
+	ids := make([]uuid.UUID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.PostID
+	}
+
+	found, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*post.Post, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	posts := make(post.Posts, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			posts = append(posts, p)
+		}
+	}
+
+	return posts, nil
+}
+
+// mergeEntries combines two timelines newest first, an author crossing the follower limit
+// can have posts in both
+func mergeEntries(a, b []Entry) []Entry {
+	merged := make([]Entry, 0, len(a)+len(b))
+	seen := make(map[uuid.UUID]bool, len(a)+len(b))
+	for _, e := range slices.Concat(a, b) {
+		if !seen[e.PostID] {
+			seen[e.PostID] = true
+			merged = append(merged, e)
+		}
+	}
+
+	slices.SortStableFunc(merged, func(x, y Entry) int {
+		return y.CreatedAt.Compare(x.CreatedAt)
+	})
+
+	return merged
 }
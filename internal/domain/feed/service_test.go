@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/post"
+	"github.com/google/uuid"
+)
+
+// memoryTimelines keeps the timelines newest first
+type memoryTimelines struct {
+	cache.TimelineStore
+	mu        sync.Mutex
+	timelines map[string]*cache.Timeline
+}
+
+func (s *memoryTimelines) PushTimelines(_ context.Context, owners []string, entry cache.TimelineEntry, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, owner := range owners {
+		if timeline, ok := s.timelines[owner]; ok {
+			timeline.Entries = append([]cache.TimelineEntry{entry}, timeline.Entries...)
+		}
+	}
+	return nil
+}
+
+func (s *memoryTimelines) ReadTimeline(_ context.Context, owner string, count int) (*cache.Timeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeline, ok := s.timelines[owner]
+	if !ok {
+		return nil, nil
+	}
+	return &cache.Timeline{Entries: timeline.Entries[:min(count, len(timeline.Entries))], Complete: timeline.Complete}, nil
+}
+
+// memoryFeed holds the published posts, a page read from SQL is recorded
+type memoryFeed struct {
+	Repository
+	posts   map[uuid.UUID]*post.Post
+	fromSQL int
+}
+
+func (r *memoryFeed) List(context.Context, uuid.UUID, *query.QueryParams) (post.Posts, error) {
+	r.fromSQL++
+	return post.Posts{}, nil
+}
+
+func (r *memoryFeed) FindByIDs(_ context.Context, ids []uuid.UUID) (post.Posts, error) {
+	posts := post.Posts{}
+	for _, id := range ids {
+		if p, ok := r.posts[id]; ok {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+
+func (r *memoryFeed) FollowedAuthors(context.Context, uuid.UUID, int64) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+// newTimeline stores a timeline of n posts newest first, the posts at the gone indexes were deleted
+func newTimeline(store *memoryTimelines, repo *memoryFeed, owner uuid.UUID, n int, complete bool, gone ...int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	timeline := &cache.Timeline{Complete: complete}
+	for i := range n {
+		ids[i] = uuid.New()
+		timeline.Entries = append(timeline.Entries, cache.TimelineEntry{PostID: ids[i].String(), PublishedAt: time.Now().Add(-time.Duration(i) * time.Minute)})
+		if !slices.Contains(gone, i) {
+			repo.posts[ids[i]] = &post.Post{ID: ids[i]}
+		}
+	}
+	store.timelines[owner.String()] = timeline
+	return ids
+}
+
+func postIDs(posts post.Posts) []uuid.UUID {
+	ids := make([]uuid.UUID, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestListFromTimelineSkipsDeletedPosts(t *testing.T) {
+	owner := uuid.New()
+	store := &memoryTimelines{timelines: map[string]*cache.Timeline{}}
+	repo := &memoryFeed{posts: map[uuid.UUID]*post.Post{}}
+	ids := newTimeline(store, repo, owner, 8, true, 1)
+	s := NewService(repo, store, &config.ConfFeed{TimelineSize: 800}).(*service)
+
+	tests := []struct {
+		offset, limit int
+		want          []uuid.UUID
+	}{
+		{0, 3, []uuid.UUID{ids[0], ids[2], ids[3]}},
+		{3, 3, []uuid.UUID{ids[4], ids[5], ids[6]}},
+		{6, 3, []uuid.UUID{ids[7]}},
+		{9, 3, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		posts, ok, err := s.listFromTimeline(context.Background(), owner, &query.QueryParams{Offset: tt.offset, Limit: tt.limit, Sort: "desc"})
+		if err != nil || !ok {
+			t.Fatalf("offset %d: ok = %v, err = %v", tt.offset, ok, err)
+		}
+		if got := postIDs(posts); !slices.Equal(got, tt.want) {
+			t.Errorf("offset %d: posts = %v, want %v", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestListFromTimelineFallsBackWhenShort(t *testing.T) {
+	owner := uuid.New()
+	store := &memoryTimelines{timelines: map[string]*cache.Timeline{}}
+	repo := &memoryFeed{posts: map[uuid.UUID]*post.Post{}}
+	// Older posts exist beyond the timeline, but most of the cached ones are gone
+	newTimeline(store, repo, owner, 4, false, 0, 1, 2)
+	s := NewService(repo, store, &config.ConfFeed{TimelineSize: 4}).(*service)
+
+	_, ok, err := s.listFromTimeline(context.Background(), owner, &query.QueryParams{Limit: 2, Sort: "desc"})
+	if err != nil || ok {
+		t.Fatalf("ok = %v, err = %v, want a fallback to SQL", ok, err)
+	}
+}
@@ -0,0 +1,26 @@
+package follow
+
+import (
+	"time"
+
+	"example.com/goapi/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// Follow represents the database model for a user following another user
+type Follow struct {
+	FollowerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FolloweeID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt  time.Time
+}
+
+// TableName overrides the table name used by Follow
+func (Follow) TableName() string {
+	return "followers"
+}
+
+// ListDTO represents a page of followers or followed users along with their total count
+type ListDTO struct {
+	Count int64       `json:"count"`
+	Users []*user.DTO `json:"users"`
+}
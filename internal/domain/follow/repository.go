@@ -0,0 +1,19 @@
+package follow
+
+import (
+	"context"
+
+	"example.com/goapi/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for the social graph.
+type Repository interface {
+	// Create returns false when the followed user doesn't exist, following twice is a no-op
+	Create(ctx context.Context, f *Follow) (bool, error)
+	Delete(ctx context.Context, followerID, followeeID uuid.UUID) error
+	// ListFollowers returns a page of the users following userID and their total count
+	ListFollowers(ctx context.Context, userID uuid.UUID, limit, offset int) (user.Users, int64, error)
+	// ListFollowing returns a page of the users followed by userID and their total count
+	ListFollowing(ctx context.Context, userID uuid.UUID, limit, offset int) (user.Users, int64, error)
+}
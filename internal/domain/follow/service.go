@@ -0,0 +1,107 @@
+package follow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// This is what the business layer of the social graph is capable off
+type Service interface {
+	Follow(ctx context.Context, userID uuid.UUID) error
+	Unfollow(ctx context.Context, userID uuid.UUID) error
+	Followers(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (user.Users, int64, error)
+	Following(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (user.Users, int64, error)
+}
+
+type service struct {
+	repo      Repository
+	timelines cache.TimelineStore
+}
+
+// NewService creates the follow service, timelines may be nil when feeds aren't cached
+func NewService(r Repository, timelines cache.TimelineStore) Service {
+	return &service{repo: r, timelines: timelines}
+}
+
+// Follow makes the caller follow the user
+func (s *service) Follow(ctx context.Context, userID uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if userData.UserID == userID {
+		return errors.New(errors.ErrInvalidRequestBody, "you cannot follow yourself", nil)
+	}
+
+	found, err := s.repo.Create(ctx, &Follow{
+		FollowerID: userData.UserID,
+		FolloweeID: userID,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	if !found {
+		return errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userID), nil)
+	}
+
+	s.resetTimeline(ctx, userData.UserID)
+	return nil
+}
+
+// Unfollow makes the caller stop following the user, unfollowing twice is a no-op
+func (s *service) Unfollow(ctx context.Context, userID uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if err := s.repo.Delete(ctx, userData.UserID, userID); err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	s.resetTimeline(ctx, userData.UserID)
+	return nil
+}
+
+// Private helper methods
+// The cached timeline of the follower holds the posts of the authors they followed before,
+// so it is dropped and rebuilt from SQL on the next read.
+func (s *service) resetTimeline(ctx context.Context, followerID uuid.UUID) {
+	if s.timelines == nil {
+		return
+	}
+
+	if err := s.timelines.DeleteTimeline(ctx, followerID.String()); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to reset timeline after follow change")
+	}
+}
+
+func (s *service) Followers(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (user.Users, int64, error) {
+	users, count, err := s.repo.ListFollowers(ctx, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, 0, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return users, count, nil
+}
+
+func (s *service) Following(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (user.Users, int64, error) {
+	users, count, err := s.repo.ListFollowing(ctx, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, 0, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return users, count, nil
+}
@@ -0,0 +1,58 @@
+package follow
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"example.com/goapi/internal/database/cache"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+type memoryFollows struct {
+	Repository
+	follows map[[2]uuid.UUID]bool
+}
+
+func (r *memoryFollows) Create(_ context.Context, f *Follow) (bool, error) {
+	r.follows[[2]uuid.UUID{f.FollowerID, f.FolloweeID}] = true
+	return true, nil
+}
+
+func (r *memoryFollows) Delete(_ context.Context, followerID, followeeID uuid.UUID) error {
+	delete(r.follows, [2]uuid.UUID{followerID, followeeID})
+	return nil
+}
+
+// memoryTimelines records which timelines were dropped
+type memoryTimelines struct {
+	cache.TimelineStore
+	deleted []string
+}
+
+func (s *memoryTimelines) DeleteTimeline(_ context.Context, owner string) error {
+	s.deleted = append(s.deleted, owner)
+	return nil
+}
+
+func TestFollowChangesResetTimeline(t *testing.T) {
+	follower, author := uuid.New(), uuid.New()
+	timelines := &memoryTimelines{}
+	s := NewService(&memoryFollows{follows: map[[2]uuid.UUID]bool{}}, timelines)
+	ctx := m.WithUserDetails(context.Background(), m.UserDataContext{UserID: follower})
+
+	if err := s.Follow(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(timelines.deleted, []string{follower.String()}) {
+		t.Fatalf("deleted timelines after follow = %v, want the follower's", timelines.deleted)
+	}
+
+	if err := s.Unfollow(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(timelines.deleted, []string{follower.String(), follower.String()}) {
+		t.Fatalf("deleted timelines after unfollow = %v, want the follower's", timelines.deleted)
+	}
+}
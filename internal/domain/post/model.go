@@ -3,12 +3,21 @@ package post
 import (
 	"time"
 
+	"example.com/goapi/internal/domain/upload"
 	"example.com/goapi/internal/domain/user"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+// Lifecycle of a post, only published posts are visible to other users
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
 // Post represents the database model for a Post
 type Post struct {
 	ID        uuid.UUID      `gorm:"primarykey"`
@@ -21,6 +30,18 @@ type Post struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Status    string         `gorm:"size:16;not null;default:published"`
+	// When a scheduled post goes live, or went live for published ones
+	PublishAt *time.Time
+	// Read only, selected by the repository from the comments table
+	CommentCount int64 `gorm:"->;-:migration"`
+	// Loaded by Repository.LoadReactions
+	Reactions      map[string]int64 `gorm:"-"`
+	ViewerReaction *string          `gorm:"-"`
+	// Loaded by Repository.LoadAttachments, in display order
+	Attachments upload.Uploads `gorm:"-"`
+	// Uploads to attach, written by Create and Update unless nil
+	AttachmentIDs []uuid.UUID `gorm:"-"`
 }
 
 // Posts represents a collection of posts
@@ -28,31 +49,77 @@ type Posts []*Post
 
 // DTO represents the data transfer object for a Post
 type DTO struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags"`
-	User      *user.DTO `json:"user,omitempty"`
-	Version   uint      `json:"version"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Tags         []string  `json:"tags"`
+	User         *user.DTO `json:"user,omitempty"`
+	Version      uint      `json:"version"`
+	Status       string    `json:"status"`
+	PublishAt    *string   `json:"publish_at"`
+	CommentCount int64     `json:"comment_count"`
+	// Number of reactions per kind and the kind the caller reacted with, if any
+	Reactions      map[string]int64 `json:"reactions"`
+	ViewerReaction *string          `json:"viewer_reaction"`
+	Attachments    []*upload.DTO    `json:"attachments"`
+	CreatedAt      string           `json:"created_at"`
+	UpdatedAt      string           `json:"updated_at"`
+	// Only set for posts in the trash
+	DeletedAt *string `json:"deleted_at,omitempty"`
 }
 
-// Form represents the input structure for creating or updating a Post
+// Form represents the input structure for creating or updating a Post.
+// Status defaults to published on create and to the current status on update,
+// scheduled posts need a publish_at in the future.
 type Form struct {
-	Title   string   `json:"title" validate:"required,max=255"`
-	Content string   `json:"content" validate:"required"`
-	Tags    []string `json:"tags"`
+	Title     string     `json:"title" validate:"required,max=255"`
+	Content   string     `json:"content" validate:"required"`
+	Tags      []string   `json:"tags"`
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt *time.Time `json:"publish_at"`
+	// IDs of uploads of the author in display order, left out on update keeps the current ones
+	Attachments []uuid.UUID `json:"attachments" validate:"omitempty,max=10,unique"`
+	// Version the update is based on, required on update unless sent as If-Match header
+	Version *uint `json:"version"`
 }
 
 // ToModel converts a Form into a Post model
 func (f *Form) ToModel() *Post {
 	return &Post{
-		ID:      uuid.New(),
-		Title:   f.Title,
-		Content: f.Content,
-		Tags:    f.Tags,
-		UserID:  &uuid.Nil,
+		ID:            uuid.New(),
+		Title:         f.Title,
+		Content:       f.Content,
+		Tags:          f.Tags,
+		UserID:        &uuid.Nil,
+		Status:        f.Status,
+		PublishAt:     f.PublishAt,
+		AttachmentIDs: f.Attachments,
+	}
+}
+
+// ToForm converts a Post model into the Form it could have been written with, e.g. as
+// the document a patch is applied to
+func (p *Post) ToForm() *Form {
+	// Tags are never null, so patches can append to them
+	tags := []string{}
+	if p.Tags != nil {
+		tags = p.Tags
+	}
+
+	attachments := make([]uuid.UUID, len(p.Attachments))
+	for i, a := range p.Attachments {
+		attachments[i] = a.ID
+	}
+
+	version := p.Version
+	return &Form{
+		Title:       p.Title,
+		Content:     p.Content,
+		Tags:        tags,
+		Status:      p.Status,
+		PublishAt:   p.PublishAt,
+		Attachments: attachments,
+		Version:     &version,
 	}
 }
 
@@ -63,15 +130,39 @@ func (p *Post) ToDto() *DTO {
 		userDto = p.User.ToDto()
 	}
 
+	var publishAt *string
+	if p.PublishAt != nil {
+		formatted := p.PublishAt.Format("2006-01-02 15:04:05")
+		publishAt = &formatted
+	}
+
+	var deletedAt *string
+	if p.DeletedAt.Valid {
+		formatted := p.DeletedAt.Time.Format("2006-01-02 15:04:05")
+		deletedAt = &formatted
+	}
+
+	reactions := p.Reactions
+	if reactions == nil {
+		reactions = map[string]int64{}
+	}
+
 	return &DTO{
-		ID:        p.ID.String(),
-		Title:     p.Title,
-		Content:   p.Content,
-		Tags:      p.Tags,
-		User:      userDto,
-		Version:   p.Version,
-		CreatedAt: p.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: p.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:             p.ID.String(),
+		Title:          p.Title,
+		Content:        p.Content,
+		Tags:           p.Tags,
+		User:           userDto,
+		Version:        p.Version,
+		CommentCount:   p.CommentCount,
+		Status:         p.Status,
+		PublishAt:      publishAt,
+		Reactions:      reactions,
+		ViewerReaction: p.ViewerReaction,
+		Attachments:    p.Attachments.ToDto(),
+		CreatedAt:      p.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      p.UpdatedAt.Format("2006-01-02 15:04:05"),
+		DeletedAt:      deletedAt,
 	}
 }
 
@@ -0,0 +1,59 @@
+package post
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// Ownership policy of posts.
+// Owners can change their own posts, users with the "any" permission (admins) can change every post.
+
+// CanView reports whether the user may see the post, unpublished posts are only visible
+// to users who may edit them. Anonymous callers pass the zero value.
+func CanView(userData m.UserDataContext, p *Post) bool {
+	return p.Status == StatusPublished || CanUpdate(userData, p)
+}
+
+// FindVisible loads the post if the caller may see it. Unpublished posts of others are
+// reported as not found, so their existence isn't revealed either.
+func FindVisible(ctx context.Context, r Repository, id uuid.UUID) (*Post, error) {
+	p, err := r.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userData, _ := m.GetUserDetailsFromContext(ctx)
+	if !CanView(userData, p) {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return p, nil
+}
+
+// CanUpdate reports whether the user may edit the post
+func CanUpdate(userData m.UserDataContext, p *Post) bool {
+	return canModify(userData, p, role.PostsUpdateOwn, role.PostsUpdateAny)
+}
+
+// CanDelete reports whether the user may delete the post
+func CanDelete(userData m.UserDataContext, p *Post) bool {
+	return canModify(userData, p, role.PostsDeleteOwn, role.PostsDeleteAny)
+}
+
+func canModify(userData m.UserDataContext, p *Post, ownPermission, anyPermission string) bool {
+	if userData.HasPermission(anyPermission) {
+		return true
+	}
+
+	return p.IsOwnedBy(userData) && userData.HasPermission(ownPermission)
+}
+
+// IsOwnedBy reports whether the post was written by the user
+func (p *Post) IsOwnedBy(userData m.UserDataContext) bool {
+	return p.UserID != nil && *p.UserID == userData.UserID
+}
@@ -0,0 +1,77 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// memoryPosts is a Repository holding the given posts. Methods the tests don't need are
+// left to the embedded interface and panic when called.
+type memoryPosts struct {
+	Repository
+	posts map[uuid.UUID]*Post
+}
+
+func (r memoryPosts) FindById(_ context.Context, id uuid.UUID) (*Post, error) {
+	p, ok := r.posts[id]
+	if !ok {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+	return p, nil
+}
+
+func TestFindVisible(t *testing.T) {
+	owner := uuid.New()
+	published := &Post{ID: uuid.New(), UserID: &owner, Status: StatusPublished}
+	draft := &Post{ID: uuid.New(), UserID: &owner, Status: StatusDraft}
+	scheduled := &Post{ID: uuid.New(), UserID: &owner, Status: StatusScheduled}
+	repo := memoryPosts{posts: map[uuid.UUID]*Post{published.ID: published, draft.ID: draft, scheduled.ID: scheduled}}
+
+	anonymous := (*m.UserDataContext)(nil)
+	author := &m.UserDataContext{UserID: owner, Permissions: []string{role.PostsUpdateOwn}}
+	other := &m.UserDataContext{UserID: uuid.New(), Permissions: []string{role.PostsUpdateOwn}}
+	admin := &m.UserDataContext{UserID: uuid.New(), Permissions: []string{role.PostsUpdateAny}}
+
+	tests := []struct {
+		name     string
+		post     *Post
+		userData *m.UserDataContext
+		visible  bool
+	}{
+		{"published to anonymous", published, anonymous, true},
+		{"published to other user", published, other, true},
+		{"draft to anonymous", draft, anonymous, false},
+		{"draft to other user", draft, other, false},
+		{"scheduled to other user", scheduled, other, false},
+		{"draft to author", draft, author, true},
+		{"scheduled to admin", scheduled, admin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.userData != nil {
+				ctx = m.WithUserDetails(ctx, *tt.userData)
+			}
+
+			p, err := FindVisible(ctx, repo, tt.post.ID)
+			if tt.visible {
+				if err != nil || p != tt.post {
+					t.Fatalf("FindVisible() = %v, %v, want the post", p, err)
+				}
+				return
+			}
+
+			apiErr, ok := err.(*errors.ApiError)
+			if !ok || apiErr.Code != errors.ErrDBNoRows {
+				t.Fatalf("err = %v, want %s", err, errors.ErrDBNoRows)
+			}
+		})
+	}
+}
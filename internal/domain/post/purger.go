@@ -0,0 +1,63 @@
+package post
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Deleted posts are purged in batches of this size
+const purgerBatchSize = 500
+
+// TrashPurger hard deletes posts that stayed in the trash longer than the retention
+type TrashPurger struct {
+	repo      Repository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(r Repository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{repo: r, retention: retention, interval: interval}
+}
+
+// Start runs the purger in the background until the context is done
+func (p *TrashPurger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.run(ctx)
+			}
+		}
+	}()
+}
+
+// run purges every expired post, batch by batch
+func (p *TrashPurger) run(ctx context.Context) {
+	logger := log.Logger.With().Str("component", "trash_purger").Logger()
+	before := time.Now().Add(-p.retention)
+
+	var total int64
+	for {
+		purged, err := p.repo.PurgeDeleted(ctx, before, purgerBatchSize)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to purge deleted posts")
+			return
+		}
+
+		total += purged
+		if purged < purgerBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logger.Info().Int64("count", total).Msg("Purged deleted posts")
+	}
+}
@@ -2,10 +2,18 @@ package post
 
 import "github.com/google/uuid"
 
+// SortMostReacted lists the posts with the most reactions first. Post lists support it on
+// top of the sorts of query.QueryParams.
+const SortMostReacted = "most_reacted"
+
 type SearchQuery struct {
 	Query   string    `json:"query;omitempty"`
 	Tags    []string  `json:"tags;omitempty"`
 	Title   string    `json:"title;omitempty"`
 	Content string    `json:"content;omitempty"`
 	UserID  uuid.UUID `json:"user_id;omitempty"`
+	// Unordered unless set
+	Sort string `json:"sort;omitempty" validate:"omitempty,oneof=asc desc most_reacted"`
+	// Set by the service, unpublished posts of the viewer are listed as well
+	ViewerID uuid.UUID `json:"-"`
 }
@@ -2,7 +2,9 @@ package post
 
 import (
 	"context"
+	"time"
 
+	"example.com/goapi/internal/common/query"
 	"github.com/google/uuid"
 )
 
@@ -11,7 +13,28 @@ type Repository interface {
 	Create(ctx context.Context, p *Post) error
 	FindAll(ctx context.Context, query *SearchQuery) (Posts, error)
 	FindById(ctx context.Context, id uuid.UUID) (*Post, error)
-	Update(ctx context.Context, input *Post) (*Post, error)
+	// Update writes the given columns of the post if it is still at input.Version,
+	// all editable columns if none are given. The replaced version is kept as a revision.
+	Update(ctx context.Context, input *Post, columns ...string) (*Post, error)
+	// DeleteById moves the post to the trash (soft delete)
 	DeleteById(ctx context.Context, id uuid.UUID) error
+	// ListTrash returns the deleted posts of a user, most recently deleted first
+	ListTrash(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (Posts, error)
+	// FindTrashed returns nil if the post does not exist or is not deleted
+	FindTrashed(ctx context.Context, id uuid.UUID) (*Post, error)
+	Restore(ctx context.Context, id uuid.UUID) (bool, error)
+	// HardDelete removes the post for good, whether it is deleted or not
+	HardDelete(ctx context.Context, id uuid.UUID) (bool, error)
+	// PurgeDeleted hard deletes up to limit posts deleted before the given time
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error)
 	SearchByText(ctx context.Context, query string) (Posts, error)
+	// PublishDue publishes up to limit scheduled posts whose publish_at has passed and
+	// returns them. Concurrent callers never receive the same post.
+	PublishDue(ctx context.Context, now time.Time, limit int) (Posts, error)
+	// LoadReactions sets the reaction counts and the reaction of the viewer (uuid.Nil if anonymous)
+	LoadReactions(ctx context.Context, posts Posts, viewerID uuid.UUID) error
+	// LoadAttachments sets the uploads attached to the posts
+	LoadAttachments(ctx context.Context, posts Posts) error
+	// CountUploads returns how many of the given uploads belong to the user
+	CountUploads(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error)
 }
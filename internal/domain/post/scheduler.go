@@ -0,0 +1,75 @@
+package post
+
+import (
+	"context"
+	"time"
+
+	"example.com/goapi/internal/database/cache"
+	"github.com/rs/zerolog/log"
+)
+
+// Due posts are published in batches of this size
+const schedulerBatchSize = 100
+
+// Scheduler publishes scheduled posts once their publish_at has passed.
+// Several instances may run at once, Repository.PublishDue hands every post to one of them.
+type Scheduler struct {
+	repo      Repository
+	publisher Publisher
+	lists     cache.PostListCache
+	interval  time.Duration
+}
+
+// NewScheduler creates the scheduler, publisher and lists may be nil
+func NewScheduler(r Repository, pub Publisher, lists cache.PostListCache, interval time.Duration) *Scheduler {
+	return &Scheduler{repo: r, publisher: pub, lists: lists, interval: interval}
+}
+
+// Start runs the scheduler in the background until the context is done
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.run(ctx)
+			}
+		}
+	}()
+}
+
+// run publishes every due post, batch by batch
+func (s *Scheduler) run(ctx context.Context) {
+	logger := log.Logger.With().Str("component", "post_scheduler").Logger()
+	ctx = logger.WithContext(ctx)
+
+	for {
+		posts, err := s.repo.PublishDue(ctx, time.Now(), schedulerBatchSize)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to publish scheduled posts")
+			return
+		}
+
+		// Cached lists were built before the posts went live
+		if len(posts) > 0 && s.lists != nil {
+			if err := s.lists.InvalidatePostLists(ctx); err != nil {
+				logger.Error().Err(err).Msg("Failed to invalidate cached post lists")
+			}
+		}
+
+		for _, p := range posts {
+			logger.Info().Str("post_id", p.ID.String()).Msg("Published scheduled post")
+			if s.publisher != nil {
+				s.publisher.PostPublished(ctx, p)
+			}
+		}
+
+		if len(posts) < schedulerBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package post
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r memoryPosts) PublishDue(_ context.Context, now time.Time, limit int) (Posts, error) {
+	due := Posts{}
+	for _, p := range r.posts {
+		if len(due) < limit && p.Status == StatusScheduled && !p.PublishAt.After(now) {
+			p.Status = StatusPublished
+			p.Version++
+			due = append(due, p)
+		}
+	}
+	return due, nil
+}
+
+// countingLists counts the invalidations of the cached post lists
+type countingLists struct {
+	invalidated int
+}
+
+func (l *countingLists) PostListKey(_ context.Context, query string) (string, error) {
+	return query, nil
+}
+
+func (l *countingLists) InvalidatePostLists(context.Context) error {
+	l.invalidated++
+	return nil
+}
+
+// recordingPublisher records the published posts
+type recordingPublisher struct {
+	published []uuid.UUID
+}
+
+func (p *recordingPublisher) PostPublished(_ context.Context, post *Post) {
+	p.published = append(p.published, post.ID)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	due := &Post{ID: uuid.New(), Status: StatusScheduled, PublishAt: &past, Version: 2}
+	later := &Post{ID: uuid.New(), Status: StatusScheduled, PublishAt: &future}
+	repo := memoryPosts{posts: map[uuid.UUID]*Post{due.ID: due, later.ID: later}}
+
+	lists, pub := &countingLists{}, &recordingPublisher{}
+	s := NewScheduler(repo, pub, lists, time.Minute)
+
+	s.run(context.Background())
+	if due.Status != StatusPublished || due.Version != 3 || later.Status != StatusScheduled {
+		t.Fatalf("due = %s v%d, later = %s", due.Status, due.Version, later.Status)
+	}
+	if len(pub.published) != 1 || pub.published[0] != due.ID {
+		t.Fatalf("published = %v, want [%s]", pub.published, due.ID)
+	}
+	if lists.invalidated != 1 {
+		t.Fatalf("lists invalidated %d times, want 1", lists.invalidated)
+	}
+
+	// Nothing due, the cached lists are kept
+	s.run(context.Background())
+	if lists.invalidated != 1 {
+		t.Fatalf("lists invalidated %d times without due posts", lists.invalidated)
+	}
+}
@@ -2,8 +2,13 @@ package post
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/role"
 	m "example.com/goapi/internal/middleware"
 	"github.com/google/uuid"
 )
@@ -13,16 +18,29 @@ type Service interface {
 	Create(ctx context.Context, input *Form) (*Post, error)
 	FindAll(ctx context.Context, query *SearchQuery) (Posts, error)
 	Update(ctx context.Context, input *Post) (*Post, error)
+	Patch(ctx context.Context, input *Post, fields []string) (*Post, error)
 	FindById(ctx context.Context, id uuid.UUID) (*Post, error)
 	DeleteById(ctx context.Context, id uuid.UUID) error
+	Trash(ctx context.Context, fq *query.QueryParams) (Posts, error)
+	Restore(ctx context.Context, id uuid.UUID) (*Post, error)
+	HardDelete(ctx context.Context, id uuid.UUID) error
+}
+
+// Publisher is notified when a post goes live, e.g. to push it into the timelines of followers.
+// This happens once per post: on create, on update to published or by the Scheduler.
+// It must not block the request for long.
+type Publisher interface {
+	PostPublished(ctx context.Context, p *Post)
 }
 
 type service struct {
-	repo Repository
+	repo      Repository
+	publisher Publisher
 }
 
-func NewService(r Repository) Service {
-	return &service{repo: r}
+// NewService creates the post service, publisher may be nil
+func NewService(r Repository, pub Publisher) Service {
+	return &service{repo: r, publisher: pub}
 }
 
 func (s *service) Create(ctx context.Context, input *Form) (*Post, error) {
@@ -37,37 +55,327 @@ func (s *service) Create(ctx context.Context, input *Form) (*Post, error) {
 
 	p := input.ToModel()
 	p.UserID = &userData.UserID
+	if err := applyStatus(p, nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAttachments(ctx, userData.UserID, p.AttachmentIDs); err != nil {
+		return nil, err
+	}
 
 	err := s.repo.Create(ctx, p)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.loadAttachments(ctx, Posts{p}); err != nil {
+		return nil, err
+	}
+
+	if p.Status == StatusPublished {
+		s.publish(ctx, p)
+	}
+
 	return p, nil
 }
 
 func (s *service) FindAll(ctx context.Context, query *SearchQuery) (Posts, error) {
-	return s.repo.FindAll(ctx, query)
+	// Unpublished posts are only listed to their owner
+	query.ViewerID = uuid.Nil
+	if userData, ok := m.GetUserDetailsFromContext(ctx); ok {
+		query.ViewerID = userData.UserID
+	}
+
+	posts, err := s.repo.FindAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
 }
 
 func (s *service) FindById(ctx context.Context, id uuid.UUID) (*Post, error) {
-	post, err := s.repo.FindById(ctx, id)
+	post, err := FindVisible(ctx, s.repo, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.loadDetails(ctx, Posts{post}); err != nil {
+		return nil, err
+	}
+
 	return post, nil
 }
 
+// patchColumns maps the fields of a Form to the columns written when they are patched.
+// Status and publish_at depend on each other, so they are always written together.
+var patchColumns = map[string][]string{
+	"title":       {"title"},
+	"content":     {"content"},
+	"tags":        {"tags"},
+	"status":      {"status", "publish_at"},
+	"publish_at":  {"status", "publish_at"},
+	"attachments": {"attachments"},
+}
+
 func (s *service) Update(ctx context.Context, input *Post) (*Post, error) {
-	updated, err := s.repo.Update(ctx, input)
+	return s.update(ctx, input, nil)
+}
+
+// Patch updates the columns of the given fields only, the others are left untouched
+// even if they changed in the meantime
+func (s *service) Patch(ctx context.Context, input *Post, fields []string) (*Post, error) {
+	columns := []string{}
+	for _, field := range fields {
+		for _, column := range patchColumns[field] {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return s.update(ctx, input, columns)
+}
+
+// update writes the columns of the post, all of them if columns is nil
+func (s *service) update(ctx context.Context, input *Post, columns []string) (*Post, error) {
+	current, err := s.authorize(ctx, input.ID, CanUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	// input.Version is the version the caller based the update on
+	if input.Version != current.Version {
+		return nil, errors.New(errors.ErrDBVersionConflict, errors.DBVersionConflict, nil)
+	}
+
+	// Nothing to write, e.g. a patch leaving the post as it is
+	if columns != nil && len(columns) == 0 {
+		return current, s.loadAttachments(ctx, Posts{current})
+	}
+
+	if columns == nil || slices.Contains(columns, "status") {
+		if err := applyStatus(input, current); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only uploads of the author can be attached, also when others edit the post
+	if columns == nil || slices.Contains(columns, "attachments") {
+		author := uuid.Nil
+		if current.UserID != nil {
+			author = *current.UserID
+		}
+
+		if err := s.checkAttachments(ctx, author, input.AttachmentIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, input, columns...)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.loadAttachments(ctx, Posts{updated}); err != nil {
+		return nil, err
+	}
+
+	if updated.Status == StatusPublished && current.Status != StatusPublished {
+		s.publish(ctx, updated)
+	}
+
 	return updated, nil
 }
 
 func (s *service) DeleteById(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.authorize(ctx, id, CanDelete); err != nil {
+		return err
+	}
+
 	return s.repo.DeleteById(ctx, id)
 }
+
+// Trash lists the deleted posts of the caller, they can be restored until they are purged
+func (s *service) Trash(ctx context.Context, fq *query.QueryParams) (Posts, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	posts, err := s.repo.ListTrash(ctx, userData.UserID, fq)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if err := s.loadAttachments(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// Restore takes a post out of the trash, allowed to those who may delete it
+func (s *service) Restore(ctx context.Context, id uuid.UUID) (*Post, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	p, err := s.repo.FindTrashed(ctx, id)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if p == nil {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	if !CanDelete(userData, p) {
+		return nil, errors.New(errors.ErrUserNotAuthorized, "you are not allowed to restore this post", nil)
+	}
+
+	restored, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	// Restored or purged in the meantime
+	if !restored {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// HardDelete removes a post for good, deleted or not. It is reserved to admins.
+func (s *service) HardDelete(ctx context.Context, id uuid.UUID) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if !userData.HasPermission(role.PostsDeleteAny) {
+		return errors.New(errors.ErrUserNotAuthorized, "you are not allowed to purge posts", nil)
+	}
+
+	deleted, err := s.repo.HardDelete(ctx, id)
+	if err != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if !deleted {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+
+	return nil
+}
+
+// applyStatus validates the requested status of the post and sets publish_at accordingly.
+// Without a status new posts are published and existing posts keep their status.
+func applyStatus(p *Post, current *Post) error {
+	if p.Status == "" {
+		if current == nil {
+			p.Status = StatusPublished
+		} else {
+			p.Status = current.Status
+			p.PublishAt = current.PublishAt
+			return nil
+		}
+	}
+
+	switch p.Status {
+	case StatusDraft:
+		p.PublishAt = nil
+	case StatusScheduled:
+		if p.PublishAt == nil || !p.PublishAt.After(time.Now()) {
+			return errors.New(errors.ErrInvalidRequestBody, "publish_at must be in the future for scheduled posts", nil)
+		}
+	case StatusPublished:
+		// Keep the original publication time when a published post is edited
+		if current != nil && current.Status == StatusPublished {
+			p.PublishAt = current.PublishAt
+		} else {
+			now := time.Now()
+			p.PublishAt = &now
+		}
+	case StatusArchived:
+		p.PublishAt = nil
+		if current != nil {
+			p.PublishAt = current.PublishAt
+		}
+	default:
+		return errors.New(errors.ErrInvalidRequestBody, fmt.Sprintf("invalid status '%s'", p.Status), nil)
+	}
+
+	return nil
+}
+
+func (s *service) publish(ctx context.Context, p *Post) {
+	if s.publisher != nil {
+		s.publisher.PostPublished(ctx, p)
+	}
+}
+
+// loadDetails adds the reactions, as seen by the caller if authenticated, and the attachments to the posts
+func (s *service) loadDetails(ctx context.Context, posts Posts) error {
+	viewerID := uuid.Nil
+	if userData, ok := m.GetUserDetailsFromContext(ctx); ok {
+		viewerID = userData.UserID
+	}
+
+	if err := s.repo.LoadReactions(ctx, posts, viewerID); err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return s.loadAttachments(ctx, posts)
+}
+
+func (s *service) loadAttachments(ctx context.Context, posts Posts) error {
+	if err := s.repo.LoadAttachments(ctx, posts); err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return nil
+}
+
+// checkAttachments makes sure all uploads to attach belong to the given user
+func (s *service) checkAttachments(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	count, err := s.repo.CountUploads(ctx, userID, ids)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if count != int64(len(ids)) {
+		return errors.New(errors.ErrInvalidRequestBody, "attachments must be uploads of the author", nil)
+	}
+
+	return nil
+}
+
+// authorize loads the post and checks the caller against the given policy
+func (s *service) authorize(ctx context.Context, id uuid.UUID, policy func(m.UserDataContext, *Post) bool) (*Post, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	p, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy(userData, p) {
+		return nil, errors.New(errors.ErrUserNotAuthorized, "you are not allowed to modify this post", nil)
+	}
+
+	return p, nil
+}
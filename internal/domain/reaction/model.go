@@ -0,0 +1,31 @@
+package reaction
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reaction represents the database model for the reaction of a user to a post
+type Reaction struct {
+	PostID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Kind      string    `gorm:"size:32;not null"`
+	CreatedAt time.Time
+}
+
+// TableName overrides the table name used by Reaction
+func (Reaction) TableName() string {
+	return "post_reactions"
+}
+
+// Form represents the input structure for reacting to a post
+type Form struct {
+	Kind string `json:"kind" validate:"required"`
+}
+
+// SummaryDTO represents the reactions of a post after the caller changed theirs
+type SummaryDTO struct {
+	Reactions      map[string]int64 `json:"reactions"`
+	ViewerReaction *string          `json:"viewer_reaction"`
+}
@@ -0,0 +1,16 @@
+package reaction
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Reactions.
+// The counts per kind are maintained by the database.
+type Repository interface {
+	// Set adds the reaction or replaces the kind of the existing one
+	Set(ctx context.Context, r *Reaction) error
+	Delete(ctx context.Context, postID, userID uuid.UUID) error
+	Counts(ctx context.Context, postID uuid.UUID) (map[string]int64, error)
+}
@@ -0,0 +1,112 @@
+package reaction
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// This is what the business layer of Reactions is capable off
+type Service interface {
+	React(ctx context.Context, postID uuid.UUID, kind string) (*SummaryDTO, error)
+	Unreact(ctx context.Context, postID uuid.UUID) (*SummaryDTO, error)
+}
+
+type service struct {
+	repo  Repository
+	posts post.Repository
+	lists cache.PostListCache
+	conf  *config.ConfPost
+}
+
+// NewService creates the reaction service, lists may be nil
+func NewService(r Repository, posts post.Repository, lists cache.PostListCache, conf *config.ConfPost) Service {
+	return &service{repo: r, posts: posts, lists: lists, conf: conf}
+}
+
+// React sets the reaction of the caller, reacting again with the same kind is a no-op
+func (s *service) React(ctx context.Context, postID uuid.UUID, kind string) (*SummaryDTO, error) {
+	if !slices.Contains(s.conf.ReactionKinds, kind) {
+		return nil, errors.New(errors.ErrInvalidRequestBody, fmt.Sprintf("kind must be one of: %s", strings.Join(s.conf.ReactionKinds, ", ")), nil)
+	}
+
+	userData, err := s.postReactor(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Set(ctx, &Reaction{
+		PostID:    postID,
+		UserID:    userData.UserID,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+	s.invalidateLists(ctx)
+
+	return s.summary(ctx, postID, &kind)
+}
+
+// Unreact removes the reaction of the caller, if any
+func (s *service) Unreact(ctx context.Context, postID uuid.UUID) (*SummaryDTO, error) {
+	userData, err := s.postReactor(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, postID, userData.UserID); err != nil {
+		return nil, errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+	s.invalidateLists(ctx)
+
+	return s.summary(ctx, postID, nil)
+}
+
+// Private helper methods
+// Returns the caller once the post is known to exist and be visible to them.
+func (s *service) postReactor(ctx context.Context, postID uuid.UUID) (m.UserDataContext, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return userData, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if _, err := post.FindVisible(ctx, s.posts, postID); err != nil {
+		return userData, err
+	}
+
+	return userData, nil
+}
+
+// Private helper methods
+func (s *service) summary(ctx context.Context, postID uuid.UUID, viewerReaction *string) (*SummaryDTO, error) {
+	counts, err := s.repo.Counts(ctx, postID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return &SummaryDTO{Reactions: counts, ViewerReaction: viewerReaction}, nil
+}
+
+// Private helper methods
+// Cached post lists show reaction counts, they are rebuilt on the next read.
+func (s *service) invalidateLists(ctx context.Context) {
+	if s.lists == nil {
+		return
+	}
+
+	if err := s.lists.InvalidatePostLists(ctx); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to invalidate cached post lists")
+	}
+}
@@ -0,0 +1,94 @@
+package reaction
+
+import (
+	"context"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// memoryReactions keeps the reactions per post and user
+type memoryReactions struct {
+	kinds map[uuid.UUID]map[uuid.UUID]string
+}
+
+func (r *memoryReactions) Set(_ context.Context, rc *Reaction) error {
+	if r.kinds[rc.PostID] == nil {
+		r.kinds[rc.PostID] = map[uuid.UUID]string{}
+	}
+	r.kinds[rc.PostID][rc.UserID] = rc.Kind
+	return nil
+}
+
+func (r *memoryReactions) Delete(_ context.Context, postID, userID uuid.UUID) error {
+	delete(r.kinds[postID], userID)
+	return nil
+}
+
+func (r *memoryReactions) Counts(_ context.Context, postID uuid.UUID) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, kind := range r.kinds[postID] {
+		counts[kind]++
+	}
+	return counts, nil
+}
+
+// publishedPosts finds any post as a published one, other methods panic
+type publishedPosts struct {
+	post.Repository
+}
+
+func (publishedPosts) FindById(_ context.Context, id uuid.UUID) (*post.Post, error) {
+	return &post.Post{ID: id, Status: post.StatusPublished}, nil
+}
+
+// countingLists counts the invalidations of the cached post lists
+type countingLists struct {
+	invalidated int
+}
+
+func (l *countingLists) PostListKey(_ context.Context, query string) (string, error) {
+	return query, nil
+}
+
+func (l *countingLists) InvalidatePostLists(context.Context) error {
+	l.invalidated++
+	return nil
+}
+
+func TestReactInvalidatesPostLists(t *testing.T) {
+	lists := &countingLists{}
+	repo := &memoryReactions{kinds: map[uuid.UUID]map[uuid.UUID]string{}}
+	s := NewService(repo, publishedPosts{}, lists, &config.ConfPost{ReactionKinds: []string{"like", "love"}})
+
+	ctx := m.WithUserDetails(context.Background(), m.UserDataContext{UserID: uuid.New()})
+	postID := uuid.New()
+
+	summary, err := s.React(ctx, postID, "like")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Reactions["like"] != 1 || lists.invalidated != 1 {
+		t.Fatalf("reactions = %v, invalidated = %d", summary.Reactions, lists.invalidated)
+	}
+
+	if _, err := s.Unreact(ctx, postID); err != nil {
+		t.Fatal(err)
+	}
+	if lists.invalidated != 2 {
+		t.Fatalf("invalidated = %d after unreact, want 2", lists.invalidated)
+	}
+
+	// Rejected reactions change nothing
+	_, err = s.React(ctx, postID, "angry")
+	if apiErr, ok := err.(*errors.ApiError); !ok || apiErr.Code != errors.ErrInvalidRequestBody {
+		t.Fatalf("err = %v, want %s", err, errors.ErrInvalidRequestBody)
+	}
+	if lists.invalidated != 2 {
+		t.Fatalf("invalidated = %d after a rejected reaction", lists.invalidated)
+	}
+}
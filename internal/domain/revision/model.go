@@ -0,0 +1,103 @@
+package revision
+
+import (
+	"time"
+
+	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/pkg/diff"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Revision represents the database model for a previous version of a post.
+// The current version lives in the posts table only.
+type Revision struct {
+	ID      uuid.UUID      `gorm:"primarykey"`
+	PostID  uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_post_revisions_version"`
+	Version uint           `gorm:"not null;uniqueIndex:idx_post_revisions_version"`
+	Title   string         `gorm:"type:varchar(255);not null"`
+	Content string         `gorm:"type:text;not null"`
+	Tags    pq.StringArray `gorm:"type:text[]"`
+	// When this version was written
+	CreatedAt time.Time
+	// Set by the service for the version stored in the posts table
+	Current bool `gorm:"-"`
+}
+
+// Revisions represents a collection of revisions
+type Revisions []*Revision
+
+func (Revision) TableName() string {
+	return "post_revisions"
+}
+
+// FromPost snapshots the current version of a post, it is stored as a revision once the post changes
+func FromPost(p *post.Post) *Revision {
+	return &Revision{
+		ID:        uuid.New(),
+		PostID:    p.ID,
+		Version:   p.Version,
+		Title:     p.Title,
+		Content:   p.Content,
+		Tags:      p.Tags,
+		CreatedAt: p.UpdatedAt,
+		Current:   true,
+	}
+}
+
+// DTO represents the data transfer object for a Revision
+type DTO struct {
+	PostID    string   `json:"post_id"`
+	Version   uint     `json:"version"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Current   bool     `json:"current"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// DiffDTO represents the line based changes between two versions of a post
+type DiffDTO struct {
+	PostID  string      `json:"post_id"`
+	From    uint        `json:"from"`
+	To      uint        `json:"to"`
+	Title   []diff.Line `json:"title"`
+	Content []diff.Line `json:"content"`
+}
+
+// ToDto converts a Revision model into a DTO
+func (r *Revision) ToDto() *DTO {
+	return &DTO{
+		PostID:    r.PostID.String(),
+		Version:   r.Version,
+		Title:     r.Title,
+		Content:   r.Content,
+		Tags:      r.Tags,
+		Current:   r.Current,
+		CreatedAt: r.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// ToListDto converts a collection of Revision models into DTOs without content
+func (items Revisions) ToListDto() []*DTO {
+	dtos := make([]*DTO, len(items))
+	for i, r := range items {
+		dto := r.ToDto()
+		dto.Content = ""
+		dto.Tags = nil
+		dtos[i] = dto
+	}
+
+	return dtos
+}
+
+// Diff compares the revision with another one
+func (r *Revision) Diff(to *Revision) *DiffDTO {
+	return &DiffDTO{
+		PostID:  r.PostID.String(),
+		From:    r.Version,
+		To:      to.Version,
+		Title:   diff.Lines(r.Title, to.Title),
+		Content: diff.Lines(r.Content, to.Content),
+	}
+}
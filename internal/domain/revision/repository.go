@@ -0,0 +1,16 @@
+package revision
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Revisions.
+// Revisions are created by the post repository along with every update.
+type Repository interface {
+	// List returns the previous versions of a post, newest first
+	List(ctx context.Context, postID uuid.UUID) (Revisions, error)
+	// Find returns nil if the post has no such previous version
+	Find(ctx context.Context, postID uuid.UUID, version uint) (*Revision, error)
+}
@@ -0,0 +1,142 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// This is what the business layer of Revisions is capable off
+type Service interface {
+	List(ctx context.Context, postID uuid.UUID) (Revisions, error)
+	Get(ctx context.Context, postID uuid.UUID, version uint) (*Revision, error)
+	Diff(ctx context.Context, postID uuid.UUID, from, to uint) (*DiffDTO, error)
+	Restore(ctx context.Context, postID uuid.UUID, version uint) (*post.Post, error)
+}
+
+type service struct {
+	repo  Repository
+	posts post.Repository
+}
+
+func NewService(r Repository, posts post.Repository) Service {
+	return &service{repo: r, posts: posts}
+}
+
+// List returns every version of a post newest first, starting with the current one
+func (s *service) List(ctx context.Context, postID uuid.UUID) (Revisions, error) {
+	p, err := s.findPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.List(ctx, postID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return append(Revisions{FromPost(p)}, revisions...), nil
+}
+
+func (s *service) Get(ctx context.Context, postID uuid.UUID, version uint) (*Revision, error) {
+	p, err := s.findPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.version(ctx, p, version)
+}
+
+// Diff returns the changes from one version of a post to another
+func (s *service) Diff(ctx context.Context, postID uuid.UUID, from, to uint) (*DiffDTO, error) {
+	p, err := s.findPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := s.version(ctx, p, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := s.version(ctx, p, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromRevision.Diff(toRevision), nil
+}
+
+// Restore writes the content of an old version as a new version of the post,
+// the version it replaces is kept as a revision like on every update.
+func (s *service) Restore(ctx context.Context, postID uuid.UUID, version uint) (*post.Post, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	p, err := s.posts.FindById(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.CanUpdate(userData, p) {
+		return nil, errors.New(errors.ErrUserNotAuthorized, "you are not allowed to modify this post", nil)
+	}
+
+	if version == p.Version {
+		return nil, errors.New(errors.ErrInvalidRequestBody, fmt.Sprintf("version %d is the current version", version), nil)
+	}
+
+	r, err := s.version(ctx, p, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the content is restored, the post keeps its lifecycle and attachments
+	restored, err := s.posts.Update(ctx, &post.Post{
+		ID:        p.ID,
+		Version:   p.Version,
+		Title:     r.Title,
+		Content:   r.Content,
+		Tags:      r.Tags,
+		Status:    p.Status,
+		PublishAt: p.PublishAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.posts.LoadAttachments(ctx, post.Posts{restored}); err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return restored, nil
+}
+
+// findPost loads the post if the caller may see it
+func (s *service) findPost(ctx context.Context, postID uuid.UUID) (*post.Post, error) {
+	return post.FindVisible(ctx, s.posts, postID)
+}
+
+// version returns the given version of the post, either the current one or a revision
+func (s *service) version(ctx context.Context, p *post.Post, version uint) (*Revision, error) {
+	if version == p.Version {
+		return FromPost(p), nil
+	}
+
+	r, err := s.repo.Find(ctx, p.ID, version)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if r == nil {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf("version %d of the post not found", version), nil)
+	}
+
+	return r, nil
+}
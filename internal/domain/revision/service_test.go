@@ -0,0 +1,187 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/diff"
+	"github.com/google/uuid"
+)
+
+type memoryRevisions struct {
+	revisions Revisions
+}
+
+func (r *memoryRevisions) List(_ context.Context, postID uuid.UUID) (Revisions, error) {
+	revisions := Revisions{}
+	for _, rev := range slices.Backward(r.revisions) {
+		if rev.PostID == postID {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *memoryRevisions) Find(_ context.Context, postID uuid.UUID, version uint) (*Revision, error) {
+	for _, rev := range r.revisions {
+		if rev.PostID == postID && rev.Version == version {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+
+// memoryPosts keeps a revision of the replaced version on update, like the post repository
+type memoryPosts struct {
+	post.Repository
+	posts     map[uuid.UUID]*post.Post
+	revisions *memoryRevisions
+}
+
+func (r *memoryPosts) FindById(_ context.Context, id uuid.UUID) (*post.Post, error) {
+	p, ok := r.posts[id]
+	if !ok {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
+	}
+	return p, nil
+}
+
+func (r *memoryPosts) Update(_ context.Context, input *post.Post, _ ...string) (*post.Post, error) {
+	p := r.posts[input.ID]
+	if p.Version != input.Version {
+		return nil, errors.New(errors.ErrDBVersionConflict, "version conflict", nil)
+	}
+
+	rev := FromPost(p)
+	rev.Current = false
+	r.revisions.revisions = append(r.revisions.revisions, rev)
+
+	updated := *p
+	updated.Title, updated.Content, updated.Tags = input.Title, input.Content, input.Tags
+	updated.Version++
+	r.posts[p.ID] = &updated
+	return &updated, nil
+}
+
+func (r *memoryPosts) LoadAttachments(context.Context, post.Posts) error {
+	return nil
+}
+
+// newPost stores a post that was written in the given versions, the last one is current
+func newPost(owner uuid.UUID, versions ...string) (*memoryPosts, *post.Post) {
+	revisions := &memoryRevisions{}
+	p := &post.Post{ID: uuid.New(), UserID: &owner, Status: post.StatusPublished, Title: "title", Content: versions[0], Version: 1}
+	posts := &memoryPosts{posts: map[uuid.UUID]*post.Post{p.ID: p}, revisions: revisions}
+
+	for _, content := range versions[1:] {
+		p, _ = posts.Update(context.Background(), &post.Post{ID: p.ID, Version: p.Version, Title: p.Title, Content: content})
+	}
+	return posts, p
+}
+
+func errorCode(err error) string {
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok {
+		return ""
+	}
+	return apiErr.Code
+}
+
+func TestList(t *testing.T) {
+	posts, p := newPost(uuid.New(), "one", "two", "three")
+	s := NewService(posts.revisions, posts)
+
+	revisions, err := s.List(context.Background(), p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions := []uint{}
+	for _, r := range revisions {
+		versions = append(versions, r.Version)
+	}
+	if !slices.Equal(versions, []uint{3, 2, 1}) {
+		t.Fatalf("versions = %v, want newest first", versions)
+	}
+	if !revisions[0].Current || revisions[1].Current {
+		t.Error("only the first version should be current")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	posts, p := newPost(uuid.New(), "a\nb\nc", "a\nc\nd")
+	s := NewService(posts.revisions, posts)
+
+	d, err := s.Diff(context.Background(), p.ID, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []diff.Line{{Op: diff.Equal, Text: "a"}, {Op: diff.Delete, Text: "b"}, {Op: diff.Equal, Text: "c"}, {Op: diff.Insert, Text: "d"}}
+	if d.From != 1 || d.To != 2 || !slices.Equal(d.Content, want) {
+		t.Errorf("diff = %+v, want %v from 1 to 2", d, want)
+	}
+	if !slices.Equal(d.Title, []diff.Line{{Op: diff.Equal, Text: "title"}}) {
+		t.Errorf("title diff = %v, want unchanged", d.Title)
+	}
+
+	if _, err := s.Diff(context.Background(), p.ID, 1, 7); errorCode(err) != errors.ErrDBNoRows {
+		t.Errorf("missing version: err = %v, want %s", err, errors.ErrDBNoRows)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	owner := uuid.New()
+	author := m.UserDataContext{UserID: owner, Permissions: []string{role.PostsUpdateOwn}}
+	other := m.UserDataContext{UserID: uuid.New(), Permissions: []string{role.PostsUpdateOwn}}
+
+	t.Run("writes the old content as a new version", func(t *testing.T) {
+		posts, p := newPost(owner, "one", "two")
+		s := NewService(posts.revisions, posts)
+
+		restored, err := s.Restore(m.WithUserDetails(context.Background(), author), p.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.Version != 3 || restored.Content != "one" {
+			t.Errorf("restored version %d with %q, want version 3 with %q", restored.Version, restored.Content, "one")
+		}
+
+		// The replaced version is kept
+		if r, _ := posts.revisions.Find(context.Background(), p.ID, 2); r == nil || r.Content != "two" {
+			t.Errorf("revision 2 = %+v, want the replaced content", r)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		userData m.UserDataContext
+		version  uint
+		code     string
+	}{
+		{"current version", author, 2, errors.ErrInvalidRequestBody},
+		{"missing version", author, 5, errors.ErrDBNoRows},
+		{"other user", other, 1, errors.ErrUserNotAuthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, p := newPost(owner, "one", "two")
+			s := NewService(posts.revisions, posts)
+
+			_, err := s.Restore(m.WithUserDetails(context.Background(), tt.userData), p.ID, tt.version)
+			if errorCode(err) != tt.code {
+				t.Fatalf("err = %v, want %s", err, tt.code)
+			}
+			if posts.posts[p.ID].Version != 2 {
+				t.Error("a failed restore changed the post")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package role
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GrantAdmins grants the admin role to the users with the given emails, it runs at startup
+// so the first admin can be created without an admin. Only verified emails are granted, so
+// nobody gets the role by signing up with an address they don't own. Users signing up later
+// are granted on the next start.
+func GrantAdmins(ctx context.Context, r Repository, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	admin, err := r.GetByName(ctx, Admin)
+	if err != nil {
+		return err
+	}
+
+	if admin == nil {
+		return fmt.Errorf("role '%s' does not exist", Admin)
+	}
+
+	logger := log.Logger.With().Str("component", "admin_bootstrap").Logger()
+	for _, email := range emails {
+		granted, err := r.GrantByEmail(ctx, email, admin.ID)
+		if err != nil {
+			return err
+		}
+
+		if !granted {
+			logger.Warn().Str("email", email).Msg("No verified user with this email, admin role not granted")
+			continue
+		}
+
+		logger.Info().Str("email", email).Msg("Granted admin role")
+	}
+
+	return nil
+}
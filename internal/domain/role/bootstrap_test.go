@@ -0,0 +1,54 @@
+package role
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// verifiedUsers is memoryRoles with users to grant roles to by email
+type verifiedUsers struct {
+	*memoryRoles
+	users map[string]uuid.UUID
+}
+
+func (r verifiedUsers) GrantByEmail(_ context.Context, email string, roleID uuid.UUID) (bool, error) {
+	userID, ok := r.users[email]
+	if !ok {
+		return false, nil
+	}
+	if !slices.Contains(r.holders[roleID], userID) {
+		r.holders[roleID] = append(r.holders[roleID], userID)
+	}
+	return true, nil
+}
+
+func TestGrantAdmins(t *testing.T) {
+	admin, other := uuid.New(), uuid.New()
+	repo := verifiedUsers{
+		memoryRoles: newMemoryRoles(),
+		users:       map[string]uuid.UUID{"admin@example.com": admin, "other@example.com": other},
+	}
+
+	// Unknown and unverified emails are skipped, granting twice is a no-op
+	emails := []string{"admin@example.com", "unknown@example.com"}
+	for range 2 {
+		if err := GrantAdmins(context.Background(), repo, emails); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	holders := repo.holders[repo.roles[Admin].ID]
+	if !slices.Equal(holders, []uuid.UUID{admin}) {
+		t.Fatalf("admins = %v, want [%s]", holders, admin)
+	}
+}
+
+func TestGrantAdminsWithoutEmails(t *testing.T) {
+	// Nothing to grant, the repository isn't touched
+	if err := GrantAdmins(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
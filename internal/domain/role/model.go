@@ -0,0 +1,111 @@
+package role
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Built-in roles
+const (
+	User  = "user"
+	Admin = "admin"
+)
+
+// Built-in permissions
+const (
+	PostsCreate    = "posts:create"
+	PostsUpdateOwn = "posts:update:own"
+	PostsDeleteOwn = "posts:delete:own"
+	PostsUpdateAny = "posts:update:any"
+	PostsDeleteAny = "posts:delete:any"
+	RolesManage    = "roles:manage"
+	UsersManage    = "users:manage"
+)
+
+// Role represents the database model for a role
+type Role struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primaryKey"`
+	Name        string        `gorm:"size:64;not null;unique"`
+	Description string        `gorm:"type:text;not null;default:''"`
+	Permissions []*Permission `gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time
+}
+
+// Roles represents a collection of roles
+type Roles []*Role
+
+// Permission represents the database model for a permission
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"size:128;not null;unique"`
+	Description string    `gorm:"type:text;not null;default:''"`
+	CreatedAt   time.Time
+}
+
+// UserRole represents a role granted to a user
+type UserRole struct {
+	UserID    uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	RoleID    uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	GrantedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt time.Time
+}
+
+// DTO represents the data transfer object for a Role
+type DTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GrantForm represents the input structure for granting a role to a user
+type GrantForm struct {
+	Role string `json:"role" validate:"required,max=64"`
+}
+
+// ToDto converts a Role model into a DTO
+func (r *Role) ToDto() *DTO {
+	permissions := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		permissions[i] = p.Name
+	}
+
+	return &DTO{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: permissions,
+	}
+}
+
+// ToDto converts a collection of Role models into a slice of DTOs
+func (items Roles) ToDto() []*DTO {
+	dtos := make([]*DTO, len(items))
+	for i, v := range items {
+		dtos[i] = v.ToDto()
+	}
+	return dtos
+}
+
+// Names returns the names of the roles
+func (items Roles) Names() []string {
+	names := make([]string, len(items))
+	for i, v := range items {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// PermissionNames returns the distinct permission names granted by the roles
+func (items Roles) PermissionNames() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, r := range items {
+		for _, p := range r.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,22 @@
+package role
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Roles.
+type Repository interface {
+	List(ctx context.Context) (Roles, error)
+	GetByName(ctx context.Context, name string) (*Role, error)
+	ListUserRoles(ctx context.Context, userID uuid.UUID) (Roles, error)
+	// Grant reports false when the user of the grant doesn't exist
+	Grant(ctx context.Context, grant *UserRole) (bool, error)
+	// GrantByEmail grants the role to the user with the verified email, false when there is none
+	GrantByEmail(ctx context.Context, email string, roleID uuid.UUID) (bool, error)
+	Revoke(ctx context.Context, userID, roleID uuid.UUID) (bool, error)
+	// RevokeUnlessLast is Revoke, but keeps the role when the user is its only holder.
+	// last reports whether that was the case.
+	RevokeUnlessLast(ctx context.Context, userID, roleID uuid.UUID) (revoked, last bool, err error)
+}
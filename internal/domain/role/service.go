@@ -0,0 +1,136 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// This is what the business layer of Roles is capable off
+type Service interface {
+	List(ctx context.Context) (Roles, error)
+	UserRoles(ctx context.Context, userID uuid.UUID) (Roles, error)
+	Grant(ctx context.Context, userID uuid.UUID, name string) error
+	Revoke(ctx context.Context, userID uuid.UUID, name string) error
+}
+
+type service struct {
+	repo        Repository
+	revocations cache.RevocationStore
+	conf        *config.ConfAuth
+}
+
+// NOTE: Permissions are part of the access tokens, rv rejects the tokens of users who lost a role.
+func NewService(r Repository, rv cache.RevocationStore, conf *config.ConfAuth) Service {
+	return &service{repo: r, revocations: rv, conf: conf}
+}
+
+func (s *service) List(ctx context.Context) (Roles, error) {
+	roles, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return roles, nil
+}
+
+func (s *service) UserRoles(ctx context.Context, userID uuid.UUID) (Roles, error) {
+	roles, err := s.repo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	return roles, nil
+}
+
+func (s *service) Grant(ctx context.Context, userID uuid.UUID, name string) error {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	role, err := s.getByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	userExists, err := s.repo.Grant(ctx, &UserRole{
+		UserID:    userID,
+		RoleID:    role.ID,
+		GrantedBy: &userData.UserID,
+	})
+	if err != nil {
+		return errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	if !userExists {
+		return errors.New(errors.ErrUserNotFound, fmt.Sprintf(errors.UserNotFound, userID), nil)
+	}
+
+	return nil
+}
+
+func (s *service) Revoke(ctx context.Context, userID uuid.UUID, name string) error {
+	role, err := s.getByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.revoke(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, name), nil)
+	}
+
+	// Tokens issued until now still carry the permissions of the role, refreshed ones won't
+	if err := s.revocations.RevokeUserTokens(ctx, userID.String(), time.Now(), s.conf.AccessTTL); err != nil {
+		return errors.New(errors.ErrInternalServer, "failed to revoke access tokens", err)
+	}
+
+	return nil
+}
+
+// Private helper methods
+// The admin role is never revoked from the last admin, nobody could grant it again.
+func (s *service) revoke(ctx context.Context, userID uuid.UUID, role *Role) (bool, error) {
+	if role.Name != Admin {
+		revoked, err := s.repo.Revoke(ctx, userID, role.ID)
+		if err != nil {
+			return false, errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+		}
+		return revoked, nil
+	}
+
+	revoked, last, err := s.repo.RevokeUnlessLast(ctx, userID, role.ID)
+	if err != nil {
+		return false, errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
+	}
+
+	if last {
+		return false, errors.New(errors.ErrInvalidRequestBody, "cannot revoke the admin role of the last admin", nil)
+	}
+
+	return revoked, nil
+}
+
+func (s *service) getByName(ctx context.Context, name string) (*Role, error) {
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if role == nil {
+		return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, name), nil)
+	}
+
+	return role, nil
+}
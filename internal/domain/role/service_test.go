@@ -0,0 +1,125 @@
+package role
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/database/cache"
+	m "example.com/goapi/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// memoryRoles holds the built-in roles and which users have them
+type memoryRoles struct {
+	Repository
+	roles   map[string]*Role
+	holders map[uuid.UUID][]uuid.UUID
+}
+
+func newMemoryRoles() *memoryRoles {
+	return &memoryRoles{
+		roles: map[string]*Role{
+			User:  {ID: uuid.New(), Name: User},
+			Admin: {ID: uuid.New(), Name: Admin},
+		},
+		holders: make(map[uuid.UUID][]uuid.UUID),
+	}
+}
+
+func (r *memoryRoles) grant(userID uuid.UUID, name string) {
+	id := r.roles[name].ID
+	r.holders[id] = append(r.holders[id], userID)
+}
+
+func (r *memoryRoles) GetByName(_ context.Context, name string) (*Role, error) {
+	return r.roles[name], nil
+}
+
+func (r *memoryRoles) Revoke(_ context.Context, userID, roleID uuid.UUID) (bool, error) {
+	i := slices.Index(r.holders[roleID], userID)
+	if i < 0 {
+		return false, nil
+	}
+	r.holders[roleID] = slices.Delete(r.holders[roleID], i, i+1)
+	return true, nil
+}
+
+func (r *memoryRoles) RevokeUnlessLast(ctx context.Context, userID, roleID uuid.UUID) (bool, bool, error) {
+	if slices.Equal(r.holders[roleID], []uuid.UUID{userID}) {
+		return false, true, nil
+	}
+	revoked, err := r.Revoke(ctx, userID, roleID)
+	return revoked, false, err
+}
+
+func newTestService(repo Repository, rv cache.RevocationStore) Service {
+	return NewService(repo, rv, &config.ConfAuth{AccessTTL: 15 * time.Minute})
+}
+
+func adminContext() context.Context {
+	return m.WithUserDetails(context.Background(), m.UserDataContext{UserID: uuid.New(), Permissions: []string{RolesManage}})
+}
+
+func TestRevokeRevokesAccessTokens(t *testing.T) {
+	repo, rv := newMemoryRoles(), cache.NewMemoryRevocationStore()
+	s := newTestService(repo, rv)
+	alice, bob := uuid.New(), uuid.New()
+	repo.grant(alice, Admin)
+	repo.grant(bob, Admin)
+
+	issuedAt := time.Now().Add(-time.Minute)
+	if err := s.Revoke(adminContext(), alice, Admin); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := rv.IsRevoked(context.Background(), "jti", alice.String(), issuedAt); !revoked {
+		t.Error("access tokens issued before the revoke are still accepted")
+	}
+	if revoked, _ := rv.IsRevoked(context.Background(), "jti", bob.String(), issuedAt); revoked {
+		t.Error("access tokens of another admin were revoked")
+	}
+}
+
+func TestRevokeKeepsLastAdmin(t *testing.T) {
+	repo, rv := newMemoryRoles(), cache.NewMemoryRevocationStore()
+	s := newTestService(repo, rv)
+	alice, bob := uuid.New(), uuid.New()
+	repo.grant(alice, Admin)
+	repo.grant(alice, User)
+	repo.grant(bob, Admin)
+
+	if err := s.Revoke(adminContext(), bob, Admin); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.Revoke(adminContext(), alice, Admin)
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != errors.ErrInvalidRequestBody {
+		t.Fatalf("err = %v, want %s", err, errors.ErrInvalidRequestBody)
+	}
+	if !slices.Contains(repo.holders[repo.roles[Admin].ID], alice) {
+		t.Fatal("the last admin lost the admin role")
+	}
+	if revoked, _ := rv.IsRevoked(context.Background(), "jti", alice.String(), time.Now().Add(-time.Minute)); revoked {
+		t.Error("tokens of the last admin were revoked although the role was kept")
+	}
+
+	// Other roles of the last admin can still be revoked
+	if err := s.Revoke(adminContext(), alice, User); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeMissingRole(t *testing.T) {
+	s := newTestService(newMemoryRoles(), cache.NewMemoryRevocationStore())
+
+	err := s.Revoke(adminContext(), uuid.New(), User)
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != errors.ErrDBNoRows {
+		t.Fatalf("err = %v, want %s", err, errors.ErrDBNoRows)
+	}
+}
@@ -0,0 +1,61 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+
+	// Decoders of the image formats whose dimensions are read
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// dimensions reads the width and height from the header of an image,
+// ok is false for other files or formats that cannot be decoded
+func dimensions(r io.Reader, mimeType string) (width, height int, ok bool) {
+	if mimeType == "image/webp" {
+		return webpDimensions(r)
+	}
+
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
+
+// webpDimensions reads the dimensions from the first chunk of a WebP file,
+// the standard library has no decoder for it
+func webpDimensions(r io.Reader) (width, height int, ok bool) {
+	header := make([]byte, 30)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, false
+	}
+
+	if !bytes.Equal(header[0:4], []byte("RIFF")) || !bytes.Equal(header[8:12], []byte("WEBP")) {
+		return 0, 0, false
+	}
+
+	switch string(header[12:16]) {
+	case "VP8 ":
+		// Lossy, 14 bit dimensions after the frame tag and start code
+		width = int(binary.LittleEndian.Uint16(header[26:28]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(header[28:30]) & 0x3fff)
+	case "VP8L":
+		// Lossless, 14 bit dimensions minus one after the signature byte
+		bits := binary.LittleEndian.Uint32(header[21:25])
+		width = int(bits&0x3fff) + 1
+		height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		// Extended, 24 bit dimensions minus one
+		width = int(uint32(header[24])|uint32(header[25])<<8|uint32(header[26])<<16) + 1
+		height = int(uint32(header[27])|uint32(header[28])<<8|uint32(header[29])<<16) + 1
+	default:
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
@@ -0,0 +1,72 @@
+package upload
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload represents the database model for an uploaded file. Identical files share
+// the blob in the store, each user gets their own record of it.
+type Upload struct {
+	ID     uuid.UUID  `gorm:"primarykey"`
+	UserID *uuid.UUID `gorm:"type:uuid"`
+	// Hex encoded SHA-256 of the content
+	Checksum   string `gorm:"type:char(64);not null"`
+	StorageKey string `gorm:"size:255;not null"`
+	URL        string `gorm:"not null"`
+	MimeType   string `gorm:"size:100;not null"`
+	Size       int64  `gorm:"not null"`
+	// Dimensions of images, nil for other files
+	Width     *int
+	Height    *int
+	CreatedAt time.Time
+}
+
+// Uploads represents a collection of uploads
+type Uploads []*Upload
+
+// Attachment links an upload to a post
+type Attachment struct {
+	PostID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UploadID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Position int       `gorm:"not null;default:0"`
+}
+
+// TableName overrides the table name used by Attachment
+func (Attachment) TableName() string {
+	return "post_attachments"
+}
+
+// DTO represents the data transfer object for an Upload
+type DTO struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	Width     *int   `json:"width"`
+	Height    *int   `json:"height"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ToDto converts an Upload model into a DTO
+func (u *Upload) ToDto() *DTO {
+	return &DTO{
+		ID:        u.ID.String(),
+		URL:       u.URL,
+		MimeType:  u.MimeType,
+		Size:      u.Size,
+		Width:     u.Width,
+		Height:    u.Height,
+		CreatedAt: u.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// ToDto converts a collection of Upload models into a slice of DTOs
+func (items Uploads) ToDto() []*DTO {
+	dtos := make([]*DTO, len(items))
+	for i, v := range items {
+		dtos[i] = v.ToDto()
+	}
+	return dtos
+}
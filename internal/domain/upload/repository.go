@@ -0,0 +1,18 @@
+package upload
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access methods for Uploads.
+type Repository interface {
+	// Create stores the upload unless the user already uploaded the same file,
+	// it reports whether the upload was created
+	Create(ctx context.Context, u *Upload) (bool, error)
+	// FindByChecksum returns the upload of the file by the user, nil if there is none
+	FindByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*Upload, error)
+	// FindStored returns any upload of the file, nil if its blob is not stored yet
+	FindStored(ctx context.Context, checksum string) (*Upload, error)
+}
@@ -0,0 +1,175 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/internal/storage"
+	"github.com/google/uuid"
+)
+
+// This is what the business layer of Uploads is capable off
+type Service interface {
+	// Upload stores size bytes of file for the caller. Uploading the same file again
+	// returns the existing upload, created reports whether a new one was made.
+	Upload(ctx context.Context, file io.ReadSeeker, size int64) (u *Upload, created bool, err error)
+}
+
+type service struct {
+	repo  Repository
+	store storage.Store
+	conf  *config.ConfMedia
+}
+
+func NewService(r Repository, store storage.Store, conf *config.ConfMedia) Service {
+	return &service{repo: r, store: store, conf: conf}
+}
+
+// extensions of the stored blobs, so they are served with the right type by any store
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func (s *service) Upload(ctx context.Context, file io.ReadSeeker, size int64) (*Upload, bool, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, false, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if size > s.conf.MaxSize {
+		return nil, false, errors.New(errors.ErrFileTooLarge, fmt.Sprintf("file must not be larger than %d bytes", s.conf.MaxSize), nil)
+	}
+
+	if size == 0 {
+		return nil, false, errors.New(errors.ErrInvalidRequestBody, "file is empty", nil)
+	}
+
+	// The type is sniffed from the content, the one claimed by the client is not trusted
+	mimeType, err := detectType(file)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrFileReadFailure, "cannot read file", err)
+	}
+
+	if !slices.Contains(s.conf.AllowedTypes, mimeType) {
+		return nil, false, errors.New(errors.ErrUnsupportedMediaType, fmt.Sprintf("file type '%s' is not allowed", mimeType), nil)
+	}
+
+	checksum, err := sha256Hex(file)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrFileReadFailure, "cannot read file", err)
+	}
+
+	existing, err := s.repo.FindByChecksum(ctx, userData.UserID, checksum)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if existing != nil {
+		return existing, false, nil
+	}
+
+	u := &Upload{
+		ID:       uuid.New(),
+		UserID:   &userData.UserID,
+		Checksum: checksum,
+		MimeType: mimeType,
+		Size:     size,
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, false, errors.New(errors.ErrFileReadFailure, "cannot read file", err)
+	}
+	if width, height, ok := dimensions(file, mimeType); ok {
+		u.Width, u.Height = &width, &height
+	}
+
+	if err := s.storeBlob(ctx, u, file); err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.repo.Create(ctx, u)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrDBInsertFailure, errors.DBDataInsertFailure, err)
+	}
+
+	// The same file was uploaded concurrently by the user
+	if !created {
+		existing, err := s.repo.FindByChecksum(ctx, userData.UserID, checksum)
+		if err != nil || existing == nil {
+			return nil, false, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+		}
+		return existing, false, nil
+	}
+
+	return u, true, nil
+}
+
+// storeBlob sets the key and URL of the upload, the blob is only written if no
+// upload of the same file exists yet
+func (s *service) storeBlob(ctx context.Context, u *Upload, file io.ReadSeeker) error {
+	stored, err := s.repo.FindStored(ctx, u.Checksum)
+	if err != nil {
+		return errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+	}
+
+	if stored != nil {
+		u.StorageKey = stored.StorageKey
+		u.URL = s.store.URL(stored.StorageKey)
+		return nil
+	}
+
+	// Content addressed, so identical files never end up under different keys
+	u.StorageKey = fmt.Sprintf("uploads/%s/%s%s", u.Checksum[:2], u.Checksum, extensions[u.MimeType])
+	if err := s.store.Put(ctx, u.StorageKey, file, u.Size, u.MimeType); err != nil {
+		return errors.New(errors.ErrFileStorageUnavailable, "cannot store file", err)
+	}
+
+	u.URL = s.store.URL(u.StorageKey)
+	return nil
+}
+
+// detectType sniffs the MIME type from the first bytes of the file, without parameters
+func detectType(file io.ReadSeeker) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "", err
+	}
+
+	return mimeType, nil
+}
+
+// sha256Hex returns the hex encoded SHA-256 of the file
+func sha256Hex(file io.ReadSeeker) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,187 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/internal/storage"
+	"github.com/google/uuid"
+)
+
+type memoryUploads struct {
+	Repository
+	uploads []*Upload
+}
+
+func (r *memoryUploads) Create(_ context.Context, u *Upload) (bool, error) {
+	r.uploads = append(r.uploads, u)
+	return true, nil
+}
+
+func (r *memoryUploads) FindByChecksum(_ context.Context, userID uuid.UUID, checksum string) (*Upload, error) {
+	for _, u := range r.uploads {
+		if *u.UserID == userID && u.Checksum == checksum {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUploads) FindStored(_ context.Context, checksum string) (*Upload, error) {
+	for _, u := range r.uploads {
+		if u.Checksum == checksum {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// memoryStore counts the blobs written, err makes every write fail
+type memoryStore struct {
+	storage.Store
+	puts int
+	err  error
+}
+
+func (s *memoryStore) Put(context.Context, string, io.ReadSeeker, int64, string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.puts++
+	return nil
+}
+
+func (s *memoryStore) URL(key string) string {
+	return "http://media.example.com/" + key
+}
+
+func pngFile(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestService(repo Repository, store storage.Store) Service {
+	return NewService(repo, store, &config.ConfMedia{
+		MaxSize:      1 << 10,
+		AllowedTypes: []string{"image/png", "image/jpeg"},
+	})
+}
+
+func withUser(userID uuid.UUID) context.Context {
+	return m.WithUserDetails(context.Background(), m.UserDataContext{UserID: userID})
+}
+
+func assertErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	apiErr, ok := err.(*errors.ApiError)
+	if !ok || apiErr.Code != code {
+		t.Fatalf("err = %v, want %s", err, code)
+	}
+}
+
+func TestUploadRejectsFiles(t *testing.T) {
+	s := newTestService(&memoryUploads{}, &memoryStore{})
+	ctx := withUser(uuid.New())
+
+	tests := []struct {
+		name    string
+		content []byte
+		size    int64
+		code    string
+	}{
+		{"too large", pngFile(t, 1, 1), 1<<10 + 1, errors.ErrFileTooLarge},
+		{"empty", []byte{}, 0, errors.ErrInvalidRequestBody},
+		{"type not allowed", []byte("GIF89a\x01\x00\x01\x00"), 10, errors.ErrUnsupportedMediaType},
+		// A text file claiming to be an image is still sniffed as text
+		{"disguised type", []byte("<html>not a png</html>"), 22, errors.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.Upload(ctx, bytes.NewReader(tt.content), tt.size)
+			assertErrorCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestUploadStoresImage(t *testing.T) {
+	repo, store := &memoryUploads{}, &memoryStore{}
+	s := newTestService(repo, store)
+
+	file := pngFile(t, 3, 2)
+
+	u, created, err := s.Upload(withUser(uuid.New()), bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || store.puts != 1 {
+		t.Fatalf("created = %v, puts = %d, want a new stored upload", created, store.puts)
+	}
+	if u.MimeType != "image/png" || u.Width == nil || *u.Width != 3 || *u.Height != 2 {
+		t.Errorf("upload = %+v, want a 3x2 png", u)
+	}
+	if u.URL != "http://media.example.com/"+u.StorageKey {
+		t.Errorf("URL = %q, want the one of the store", u.URL)
+	}
+}
+
+func TestUploadDeduplicatesByChecksum(t *testing.T) {
+	repo, store := &memoryUploads{}, &memoryStore{}
+	s := newTestService(repo, store)
+	alice, bob := uuid.New(), uuid.New()
+	file := pngFile(t, 1, 1)
+
+	first, _, err := s.Upload(withUser(alice), bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same user gets the existing upload back
+	again, created, err := s.Upload(withUser(alice), bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || again.ID != first.ID {
+		t.Fatalf("second upload of alice created = %v, id = %s, want the first upload", created, again.ID)
+	}
+
+	// Another user gets an upload of their own, sharing the stored blob
+	other, created, err := s.Upload(withUser(bob), bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || other.ID == first.ID || other.StorageKey != first.StorageKey {
+		t.Fatalf("upload of bob = %+v, want a new upload of the same blob", other)
+	}
+
+	if store.puts != 1 {
+		t.Fatalf("blobs written = %d, want 1", store.puts)
+	}
+}
+
+func TestUploadStorageUnavailable(t *testing.T) {
+	repo := &memoryUploads{}
+	s := newTestService(repo, &memoryStore{err: stderrors.New("connection refused")})
+	file := pngFile(t, 1, 1)
+
+	_, _, err := s.Upload(withUser(uuid.New()), bytes.NewReader(file), int64(len(file)))
+	assertErrorCode(t, err, errors.ErrFileStorageUnavailable)
+
+	if len(repo.uploads) != 0 {
+		t.Fatal("upload was recorded although its blob wasn't stored")
+	}
+}
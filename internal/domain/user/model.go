@@ -16,6 +16,11 @@ type User struct {
 	IsVerified bool      `gorm:"not null;default:false"`
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	// Two-factor authentication, the secret is encrypted and only set once enrollment started
+	TOTPSecret   *string `gorm:"column:totp_secret"`
+	TOTPEnabled  bool    `gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastStep int64   `gorm:"column:totp_last_step;not null;default:0"`
 }
 
 // Users represent the list of user
@@ -23,12 +28,13 @@ type Users []*User
 
 // DTO represents the data transfer object for User
 type DTO struct {
-	ID         string `json:"id"`
-	Username   string `json:"username"`
-	Email      string `json:"email"`
-	IsVerified bool   `json:"is_verified"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	IsVerified  bool   `json:"is_verified"`
+	TOTPEnabled bool   `json:"two_factor_enabled"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
 }
 
 // Form represent the input structure for creating user or updating user
@@ -38,6 +44,21 @@ type Form struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+// UpdateForm represents the fields of a user that can be changed after sign up.
+// The password is changed through the auth endpoints. The rules match the ones of sign up.
+type UpdateForm struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+}
+
+// ToUpdateForm converts a User model into its UpdateForm, e.g. as the document a patch is applied to
+func (u *User) ToUpdateForm() *UpdateForm {
+	return &UpdateForm{
+		Username: u.Username,
+		Email:    u.Email,
+	}
+}
+
 // ToModel converts a form to user model
 func (f *Form) ToModel() (*User, error) {
 	hash, err := HashPassword(f.Password)
@@ -57,12 +78,13 @@ func (f *Form) ToModel() (*User, error) {
 // ToDto converts a User model to a DTO
 func (u *User) ToDto() *DTO {
 	return &DTO{
-		ID:         u.ID.String(),
-		Username:   u.Username,
-		Email:      u.Email,
-		IsVerified: u.IsVerified,
-		CreatedAt:  u.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:  u.CreatedAt.Format("2006-01-02 15:04:05"),
+		ID:          u.ID.String(),
+		Username:    u.Username,
+		Email:       u.Email,
+		IsVerified:  u.IsVerified,
+		TOTPEnabled: u.TOTPEnabled,
+		CreatedAt:   u.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   u.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
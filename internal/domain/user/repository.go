@@ -12,5 +12,10 @@ type Repository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	Update(ctx context.Context, user *User) (*User, error)
+	// Patch writes the given columns of the user only
+	Patch(ctx context.Context, user *User, columns ...string) (*User, error)
+	// UsernameTaken and EmailTaken report whether another user already uses the value
+	UsernameTaken(ctx context.Context, username string, exceptID uuid.UUID) (bool, error)
+	EmailTaken(ctx context.Context, email string, exceptID uuid.UUID) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
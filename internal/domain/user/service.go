@@ -2,9 +2,13 @@ package user
 
 import (
 	"context"
+	"slices"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 )
 
 // This is what the business layer of Users is capable off
@@ -13,15 +17,22 @@ type Service interface {
 	Create(ctx context.Context, form *Form) (*User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
 	Update(ctx context.Context, user *User) (*User, error)
+	Patch(ctx context.Context, id uuid.UUID, input *UpdateForm, fields []string) (*User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// EmailVerifier sends a verification email to the current address of a user
+type EmailVerifier interface {
+	ResendVerification(ctx context.Context, email string) error
+}
+
 type service struct {
-	repo Repository
+	repo     Repository
+	verifier EmailVerifier
 }
 
-func NewService(r Repository) Service {
-	return &service{repo: r}
+func NewService(r Repository, verifier EmailVerifier) Service {
+	return &service{repo: r, verifier: verifier}
 }
 
 func (s *service) List(ctx context.Context) (Users, error) {
@@ -60,6 +71,70 @@ func (s *service) Update(ctx context.Context, user *User) (*User, error) {
 	return user, nil
 }
 
+// Patch updates the given fields of a user, users can patch themselves and user managers everyone.
+// A new email has to be verified again, the verification email is sent to it.
+func (s *service) Patch(ctx context.Context, id uuid.UUID, input *UpdateForm, fields []string) (*User, error) {
+	userData, ok := m.GetUserDetailsFromContext(ctx)
+	if !ok {
+		return nil, errors.New(errors.ErrInternalServer, "cannot get userID from session", nil)
+	}
+
+	if userData.UserID != id && !userData.HasPermission(role.UsersManage) {
+		return nil, errors.New(errors.ErrUserNotAuthorized, "you are not allowed to modify this user", nil)
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{}
+	if slices.Contains(fields, "username") && input.Username != user.Username {
+		taken, err := s.repo.UsernameTaken(ctx, input.Username, id)
+		if err != nil {
+			return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+		}
+		if taken {
+			return nil, errors.New(errors.ErrUsernameTaken, "username is already taken", nil)
+		}
+
+		user.Username = input.Username
+		columns = append(columns, "username")
+	}
+
+	if slices.Contains(fields, "email") && input.Email != user.Email {
+		taken, err := s.repo.EmailTaken(ctx, input.Email, id)
+		if err != nil {
+			return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
+		}
+		if taken {
+			return nil, errors.New(errors.ErrEmailAlreadyUsed, "email is already used", nil)
+		}
+
+		user.Email = input.Email
+		user.IsVerified = false
+		columns = append(columns, "email", "is_verified")
+	}
+
+	if len(columns) == 0 {
+		return user, nil
+	}
+
+	patched, err := s.repo.Patch(ctx, user, columns...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The change is stored at this point, a failed email can be sent again via ResendVerification
+	if slices.Contains(columns, "email") {
+		if err := s.verifier.ResendVerification(ctx, patched.Email); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Str("user_id", patched.ID.String()).Msg("Failed to send verification email")
+		}
+	}
+
+	return patched, nil
+}
+
 func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, err)
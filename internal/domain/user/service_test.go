@@ -0,0 +1,96 @@
+package user
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	m "example.com/goapi/internal/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type memoryUsers struct {
+	Repository
+	users map[uuid.UUID]*User
+}
+
+func (r *memoryUsers) GetByID(_ context.Context, id uuid.UUID) (*User, error) {
+	u := *r.users[id]
+	return &u, nil
+}
+
+func (r *memoryUsers) Patch(_ context.Context, user *User, _ ...string) (*User, error) {
+	u := *user
+	r.users[user.ID] = &u
+	return user, nil
+}
+
+func (r *memoryUsers) UsernameTaken(context.Context, string, uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryUsers) EmailTaken(context.Context, string, uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// memoryVerifier records the addresses verification emails were sent to
+type memoryVerifier struct {
+	sent []string
+}
+
+func (v *memoryVerifier) ResendVerification(_ context.Context, email string) error {
+	v.sent = append(v.sent, email)
+	return nil
+}
+
+func TestPatchEmailSendsVerification(t *testing.T) {
+	id := uuid.New()
+	repo := &memoryUsers{users: map[uuid.UUID]*User{
+		id: {ID: id, Username: "alice", Email: "alice@example.com", IsVerified: true},
+	}}
+	verifier := &memoryVerifier{}
+	s := NewService(repo, verifier)
+	ctx := m.WithUserDetails(context.Background(), m.UserDataContext{UserID: id})
+
+	if _, err := s.Patch(ctx, id, &UpdateForm{Username: "alice_2"}, []string{"username"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(verifier.sent) != 0 {
+		t.Fatalf("verification sent on a username change: %v", verifier.sent)
+	}
+
+	patched, err := s.Patch(ctx, id, &UpdateForm{Email: "new@example.com"}, []string{"email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.IsVerified || repo.users[id].IsVerified {
+		t.Error("user is still verified after changing the email")
+	}
+	if !slices.Equal(verifier.sent, []string{"new@example.com"}) {
+		t.Errorf("verification sent to %v, want the new address", verifier.sent)
+	}
+}
+
+func TestUpdateFormAcceptsRegisteredUsernames(t *testing.T) {
+	v := validator.New()
+
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"alice", true},
+		{"alice4821", true},
+		{"alice.smith-2", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			err := v.Struct(&UpdateForm{Username: tt.username, Email: "alice@example.com"})
+			if (err == nil) != tt.valid {
+				t.Errorf("validate(%q) = %v, want valid %v", tt.username, err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/auth"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// ListAPIKeys handles listing the API keys of the caller
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing api keys failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing api keys")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]*auth.APIKeyDTO, len(keys))
+	for i, key := range keys {
+		dtos[i] = key.ToDto()
+	}
+
+	httpx.Ok(w, dtos)
+}
+
+// CreateAPIKey handles creating an API key, the key is only part of this response
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.CreateAPIKeyPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	key, plain, err := h.service.CreateAPIKey(r.Context(), payload)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Creating api key failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during creating api key")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Created(w, &auth.CreatedAPIKeyDTO{APIKeyDTO: key.ToDto(), Key: plain})
+}
+
+// RevokeAPIKey handles deleting an API key of the caller
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Revoking api key failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during revoking api key")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "API key revoked"})
+}
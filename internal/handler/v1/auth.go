@@ -2,39 +2,80 @@ package v1
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"math"
+	"net"
 	"net/http"
-	"time"
+	"strconv"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/config"
 	"example.com/goapi/internal/domain/auth"
+	"example.com/goapi/internal/domain/role"
 	m "example.com/goapi/internal/middleware"
 	_v "example.com/goapi/internal/utils/validator"
 	"example.com/goapi/pkg/httpx"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 type AuthHandler struct {
 	service   auth.Service
 	validator *validator.Validate
+	authn     *m.Authenticator
+	conf      *config.ConfAuth
 }
 
-func NewAuthHandler(s auth.Service, v *validator.Validate) *AuthHandler {
-	return &AuthHandler{service: s, validator: v}
+func NewAuthHandler(s auth.Service, v *validator.Validate, authn *m.Authenticator, conf *config.ConfAuth) *AuthHandler {
+	return &AuthHandler{service: s, validator: v, authn: authn, conf: conf}
 }
 
 func (h *AuthHandler) RegisterAuthRoutes(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", h.RegisterUser)
 		r.Post("/login", h.Login)
+		r.Post("/login/2fa", h.LoginTwoFactor)
+		r.Post("/refresh", h.RefreshTokens)
+		r.Post("/verify", h.VerifyEmail)
+		r.Post("/verify/resend", h.ResendVerification)
+		r.Post("/password/forgot", h.ForgotPassword)
+		r.Post("/password/reset", h.ResetPassword)
 
+		r.Get("/oidc/{provider}/login", h.StartOIDCLogin)
+		r.Get("/oidc/{provider}/callback", h.OIDCCallback)
+
+		// Account management, not available to API keys
 		r.Group(func(r chi.Router) {
-			r.Use(m.Authenticate())
+			r.Use(h.authn.Authenticate)
+			r.Use(m.RequireInteractiveSession)
 			r.Post("/logout", h.Logout)
-			r.Post("/refresh", h.RefreshTokens)
+			r.Post("/password/change", h.ChangePassword)
+
+			r.Post("/2fa/setup", h.SetupTwoFactor)
+			r.Post("/2fa/confirm", h.ConfirmTwoFactor)
+			r.Post("/2fa/disable", h.DisableTwoFactor)
+
+			r.Get("/sessions", h.ListSessions)
+			r.Delete("/sessions", h.RevokeAllSessions)
+			r.Delete("/sessions/{id}", h.RevokeSession)
+
+			r.Post("/oidc/{provider}/link", h.StartOIDCLink)
+			r.Get("/identities", h.ListIdentities)
+			r.Delete("/identities/{id}", h.UnlinkIdentity)
+
+			r.Get("/api-keys", h.ListAPIKeys)
+			r.Post("/api-keys", h.CreateAPIKey)
+			r.Delete("/api-keys/{id}", h.RevokeAPIKey)
 		})
 	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Use(m.RequirePermission(role.UsersManage))
+		r.Post("/admin/users/{id}/signout", h.SignOutUser)
+	})
 }
 
 // RegisterUser handles user registration
@@ -75,8 +116,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	logger := zerolog.Ctx(r.Context())
 
 	var credentials struct {
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		Email      string `json:"email" validate:"required,email"`
+		Password   string `json:"password" validate:"required,min=8"`
+		DeviceName string `json:"device_name" validate:"max=255"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
@@ -90,11 +132,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.service.Login(r.Context(), credentials.Email, credentials.Password)
+	device := deviceFromRequest(r, credentials.DeviceName)
+	result, err := h.service.Login(r.Context(), credentials.Email, credentials.Password, device)
 	if err != nil {
 		if apiErr, ok := err.(*errors.ApiError); ok {
 			logger.Error().Err(err).Msg("Login failed")
-			httpx.Error(w, apiErr.Message, http.StatusInternalServerError)
+			setRetryAfter(w, apiErr)
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
 			return
 		}
 
@@ -103,22 +147,50 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set refresh token as HTTP-only cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true, // Enable in production (HTTPS only)
-		SameSite: http.SameSiteStrictMode,
-		Expires:  time.Now().Add(1 * 24 * time.Hour), // 1 day
-		// MaxAge:   int(24 * time.Hour * 7), // This is redundant but can be used together with expires because some browsers still check this attribute
-	})
+	// The password was right but a second factor is needed, see LoginTwoFactor
+	if result.TwoFactorRequired() {
+		httpx.Ok(w, map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     result.ChallengeToken,
+		})
+		return
+	}
 
-	httpx.Ok(w, map[string]any{
-		"access_token": tokens.AccessToken,
-		"expires_in":   "15 mins",
-	})
+	h.writeTokens(w, result.Tokens, h.cookieMode(r))
+}
+
+// LoginTwoFactor handles completing a login with a TOTP or recovery code
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.TwoFactorLoginPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	device := deviceFromRequest(r, payload.DeviceName)
+	tokens, err := h.service.LoginTwoFactor(r.Context(), payload.ChallengeToken, payload.Code, device)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Two-factor login failed")
+			setRetryAfter(w, apiErr)
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during two-factor login")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, tokens, h.cookieMode(r))
 }
 
 // Logout handles user logout
@@ -126,18 +198,16 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	logger := zerolog.Ctx(r.Context())
 
 	// Get refresh token from cookie
-	cookie, err := r.Cookie("refresh_token")
+	cookie, err := r.Cookie(m.RefreshTokenCookie)
 	if err != nil {
 		httpx.Error(w, "Missing refresh token", http.StatusBadRequest)
 		return
 	}
-	// If cookie exists, log its value
-	logger.Debug().Str("refresh_token", cookie.Value).Msg("Retrieved refresh token")
 
 	if err := h.service.Logout(r.Context(), cookie.Value); err != nil {
 		if apiErr, ok := err.(*errors.ApiError); ok {
 			logger.Error().Err(err).Msg("Logout failed")
-			httpx.Error(w, apiErr.Message, http.StatusInternalServerError)
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
 			return
 		}
 
@@ -146,16 +216,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Clear the refresh token cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   -1, // Immediately expire the cookie
-	})
+	h.clearAuthCookies(w)
 	httpx.Ok(w, map[string]string{"message": "Successfully logged out"})
 }
 
@@ -164,17 +225,17 @@ func (h *AuthHandler) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 	logger := zerolog.Ctx(r.Context())
 
 	// Get refresh token from cookie
-	cookie, err := r.Cookie("refresh_token")
+	cookie, err := r.Cookie(m.RefreshTokenCookie)
 	if err != nil {
 		httpx.Error(w, "Missing refresh token", http.StatusBadRequest)
 		return
 	}
 
-	tokens, err := h.service.RefreshTokens(r.Context(), cookie.Value)
+	tokens, err := h.service.RefreshTokens(r.Context(), cookie.Value, deviceFromRequest(r, ""))
 	if err != nil {
 		if apiErr, ok := err.(*errors.ApiError); ok {
 			logger.Error().Err(err).Msg("Token refresh failed")
-			httpx.Error(w, apiErr.Message, http.StatusInternalServerError)
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
 			return
 		}
 
@@ -183,19 +244,362 @@ func (h *AuthHandler) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set new refresh token as HTTP-only cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   int(24 * time.Hour * 7), // 7 days
-	})
+	h.writeTokens(w, tokens, h.cookieMode(r))
+}
 
-	httpx.Ok(w, map[string]interface{}{
-		"access_token": tokens.AccessToken,
-		"expires_in":   "15 mins",
-	})
+// VerifyEmail handles confirming the email address of a user with a token sent by email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.VerifyEmailPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), payload.Token); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Email verification failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during email verification")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Email verified"})
+}
+
+// ResendVerification handles sending a new verification email
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.ResendVerificationPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResendVerification(r.Context(), payload.Email); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Resending verification failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during resending verification")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Same response whether the email exists or not
+	httpx.Ok(w, map[string]string{"message": "If the email belongs to an unverified account, a verification email has been sent"})
+}
+
+// ForgotPassword handles sending a password reset email
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.ForgotPasswordPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ForgotPassword(r.Context(), payload.Email); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Forgot password failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during forgot password")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Same response whether the email exists or not
+	httpx.Ok(w, map[string]string{"message": "If the email belongs to an account, a password reset email has been sent"})
+}
+
+// ResetPassword handles setting a new password with a token sent by email
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.ResetPasswordPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), payload.Token, payload.Password); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Password reset failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during password reset")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Password has been reset"})
+}
+
+// ChangePassword handles changing the password of the caller
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.ChangePasswordPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ChangePassword(r.Context(), payload.CurrentPassword, payload.NewPassword); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Password change failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during password change")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Password has been changed"})
+}
+
+// ListSessions handles listing the active sessions of the caller
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	sessions, err := h.service.ListSessions(r.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing sessions failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing sessions")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]*auth.SessionDTO, len(sessions))
+	for i, session := range sessions {
+		dtos[i] = session.ToSessionDto()
+	}
+
+	httpx.Ok(w, dtos)
+}
+
+// RevokeSession handles revoking a single session of the caller
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Revoking session failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during revoking session")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Session revoked"})
+}
+
+// RevokeAllSessions handles revoking every session and API key of the caller
+func (h *AuthHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	if err := h.service.RevokeAllSessions(r.Context()); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Revoking sessions failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during revoking sessions")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "All sessions revoked"})
+}
+
+// deviceFromRequest collects the client details of a session from the request.
+// NOTE: RemoteAddr is already rewritten by the RealIP middleware when running behind a trusted proxy.
+func deviceFromRequest(r *http.Request, deviceName string) *auth.DeviceInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	return &auth.DeviceInfo{
+		DeviceName: deviceName,
+		UserAgent:  r.UserAgent(),
+		IPAddress:  ip,
+	}
+}
+
+// SignOutUser handles revoking every session, access token and API key of a user
+func (h *AuthHandler) SignOutUser(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SignOutUser(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Signing out user failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during signing out user")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "User signed out"})
+}
+
+// SetupTwoFactor handles starting the TOTP enrollment of the caller
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	setup, err := h.service.SetupTwoFactor(r.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Two-factor setup failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during two-factor setup")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, setup)
+}
+
+// ConfirmTwoFactor handles enabling TOTP with a first code, responds with the recovery codes
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.TwoFactorCodePayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(r.Context(), payload.Code)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Two-factor confirmation failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during two-factor confirmation")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]any{"recovery_codes": codes})
+}
+
+// DisableTwoFactor handles turning TOTP off for the caller
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &auth.DisableTwoFactorPayload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		httpx.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(payload); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(r.Context(), payload.Password, payload.Code); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Disabling two-factor failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during disabling two-factor")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// setRetryAfter tells the client when to try again if the error is a login lockout
+func setRetryAfter(w http.ResponseWriter, apiErr *errors.ApiError) {
+	var lockout *auth.LockoutError
+	if stderrors.As(apiErr, &lockout) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds()))))
+	}
 }
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "example.com/goapi/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// scopedKeys resolves every key to a key of one user with a narrow scope
+type scopedKeys struct{}
+
+func (scopedKeys) VerifyAPIKey(ctx context.Context, key string) (*m.UserDataContext, error) {
+	return &m.UserDataContext{
+		UserID:      uuid.New(),
+		Permissions: []string{"posts:create"},
+		APIKeyID:    uuid.New(),
+	}, nil
+}
+
+// Account management must not be reachable with an API key, whatever its scopes.
+// The services are never called, so the handlers are built without them.
+func TestAccountManagementRejectsAPIKeys(t *testing.T) {
+	authn := m.NewAuthenticator(m.AuthOptions{APIKeys: scopedKeys{}})
+
+	r := chi.NewRouter()
+	NewAuthHandler(nil, nil, authn, nil).RegisterAuthRoutes(r)
+	NewUserHandler(nil, nil, authn).RegisterUserRoutes(r)
+
+	id := uuid.NewString()
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/auth/logout"},
+		{http.MethodPost, "/auth/password/change"},
+		{http.MethodPost, "/auth/2fa/setup"},
+		{http.MethodPost, "/auth/2fa/confirm"},
+		{http.MethodPost, "/auth/2fa/disable"},
+		{http.MethodGet, "/auth/sessions"},
+		{http.MethodDelete, "/auth/sessions"},
+		{http.MethodDelete, "/auth/sessions/" + id},
+		{http.MethodPost, "/auth/oidc/google/link"},
+		{http.MethodGet, "/auth/identities"},
+		{http.MethodDelete, "/auth/identities/" + id},
+		{http.MethodGet, "/auth/api-keys"},
+		{http.MethodPost, "/auth/api-keys"},
+		{http.MethodDelete, "/auth/api-keys/" + id},
+		{http.MethodPut, "/users/" + id},
+		{http.MethodPatch, "/users/" + id},
+	}
+
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			req.Header.Set("Authorization", "ApiKey goapi_abc_secret")
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/bookmark"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type BookmarkHandler struct {
+	service   bookmark.Service
+	validator *validator.Validate
+	authn     *m.Authenticator
+}
+
+func NewBookmarkHandler(s bookmark.Service, v *validator.Validate, authn *m.Authenticator) *BookmarkHandler {
+	return &BookmarkHandler{service: s, validator: v, authn: authn}
+}
+
+// RegisterBookmarkRoutes mounts the bookmark routes on the given router
+func (h *BookmarkHandler) RegisterBookmarkRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Post("/posts/{id}/bookmark", h.BookmarkPost)
+		r.Delete("/posts/{id}/bookmark", h.RemoveBookmark)
+
+		r.Route("/users/me/bookmarks", func(r chi.Router) {
+			r.Get("/", h.ListBookmarks)
+			r.Get("/collections", h.ListCollections)
+			r.Post("/collections", h.CreateCollection)
+			r.Delete("/collections/{id}", h.DeleteCollection)
+		})
+	})
+}
+
+// BookmarkPost godoc
+//
+//	@Summary		Bookmark a post
+//	@Description	Save a post to read later, optionally into a collection. Bookmarking again moves it to the collection.
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string			true	"Post ID"
+//	@Param			bookmark	body		bookmark.Form	false	"Collection of the bookmark"
+//	@Success		200			{object}	httpx.APIResponse
+//	@Failure		400			{object}	httpx.APIResponse
+//	@Failure		404			{object}	httpx.APIResponse
+//	@Failure		500			{object}	httpx.APIResponse
+//	@Router			/posts/{id}/bookmark [post]
+func (h *BookmarkHandler) BookmarkPost(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional
+	input := &bookmark.Form{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil && err != io.EOF {
+		httpx.Error(w, errors.JSONDecodeFailure, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Bookmark(r.Context(), postID, input); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Bookmarking post failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during bookmarking post")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Post bookmarked"})
+}
+
+// RemoveBookmark godoc
+//
+//	@Summary		Remove bookmark
+//	@Description	Remove a post from the bookmarks of the caller
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{object}	httpx.APIResponse
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/posts/{id}/bookmark [delete]
+func (h *BookmarkHandler) RemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RemoveBookmark(r.Context(), postID); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Removing bookmark failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during removing bookmark")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Bookmark removed"})
+}
+
+// ListBookmarks godoc
+//
+//	@Summary		List bookmarks
+//	@Description	Returns a paginated list of the posts bookmarked by the caller
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collection_id	query		string		false	"Only bookmarks of this collection"
+//	@Param			limit			query		int			false	"Number of items to return (default 20)"
+//	@Param			offset			query		int			false	"Offset for pagination (default 0)"
+//	@Param			sort			query		string		false	"Sort order: 'asc', 'desc' or 'most_reacted' (default 'desc')"
+//	@Param			tags			query		[]string	false	"Filter by tags"
+//	@Param			search			query		string		false	"Search keyword in title/content"
+//	@Success		200				{array}		post.DTO
+//	@Failure		400				{object}	httpx.APIResponse
+//	@Failure		404				{object}	httpx.APIResponse
+//	@Failure		422				{object}	httpx.APIResponse
+//	@Failure		500				{object}	httpx.APIResponse
+//	@Router			/users/me/bookmarks [get]
+func (h *BookmarkHandler) ListBookmarks(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	var collectionID *uuid.UUID
+	if idx := r.URL.Query().Get("collection_id"); idx != "" {
+		id, err := uuid.Parse(idx)
+		if err != nil {
+			httpx.Error(w, "invalid collection_id", http.StatusBadRequest)
+			return
+		}
+		collectionID = &id
+	}
+
+	fq, ok := parseQueryParams(w, r, h.validator, post.SortMostReacted)
+	if !ok {
+		return
+	}
+
+	posts, err := h.service.List(r.Context(), collectionID, fq)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing bookmarks failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing bookmarks")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, posts.ToDto())
+}
+
+// ListCollections godoc
+//
+//	@Summary		List bookmark collections
+//	@Description	Get the bookmark collections of the caller
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		bookmark.CollectionDTO
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/users/me/bookmarks/collections [get]
+func (h *BookmarkHandler) ListCollections(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	collections, err := h.service.ListCollections(r.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing bookmark collections failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing bookmark collections")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, collections.ToDto())
+}
+
+// CreateCollection godoc
+//
+//	@Summary		Create bookmark collection
+//	@Description	Create a named collection for bookmarks
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collection	body		bookmark.CollectionForm	true	"Collection name"
+//	@Success		201			{object}	bookmark.CollectionDTO
+//	@Failure		400			{object}	httpx.APIResponse
+//	@Failure		409			{object}	httpx.APIResponse
+//	@Failure		422			{object}	httpx.APIResponse
+//	@Failure		500			{object}	httpx.APIResponse
+//	@Router			/users/me/bookmarks/collections [post]
+func (h *BookmarkHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	input := &bookmark.CollectionForm{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		httpx.Error(w, errors.JSONDecodeFailure, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	collection, err := h.service.CreateCollection(r.Context(), input)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Creating bookmark collection failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during creating bookmark collection")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Created(w, collection.ToDto())
+}
+
+// DeleteCollection godoc
+//
+//	@Summary		Delete bookmark collection
+//	@Description	Delete a collection, its bookmarks are kept without collection
+//	@Tags			Bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Collection ID"
+//	@Success		200	{string}	string	"Deleted message"
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/users/me/bookmarks/collections/{id} [delete]
+func (h *BookmarkHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteCollection(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Deleting bookmark collection failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during deleting bookmark collection")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, fmt.Sprintf("Deleted Collection with id `%s`", id))
+}
@@ -0,0 +1,224 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/comment"
+	m "example.com/goapi/internal/middleware"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type CommentHandler struct {
+	service   comment.Service
+	validator *validator.Validate
+	authn     *m.Authenticator
+}
+
+func NewCommentHandler(s comment.Service, v *validator.Validate, authn *m.Authenticator) *CommentHandler {
+	return &CommentHandler{service: s, validator: v, authn: authn}
+}
+
+// RegisterCommentRoutes mounts the comment routes on the given router
+func (h *CommentHandler) RegisterCommentRoutes(r chi.Router) {
+	r.Get("/posts/{id}/comments", h.ListComments)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Post("/posts/{id}/comments", h.CreateComment)
+		r.Put("/comments/{id}", h.UpdateComment)
+		r.Delete("/comments/{id}", h.DeleteComment)
+	})
+}
+
+// ListComments godoc
+//
+//	@Summary		List comments of a post
+//	@Description	Get the comments of a post as threads, replies are nested under their parent
+//	@Tags			Comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{array}		comment.DTO
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/posts/{id}/comments [get]
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	comments, err := h.service.List(r.Context(), postID)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing comments failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing comments")
+		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, comments.ToDto())
+}
+
+// CreateComment godoc
+//
+//	@Summary		Comment on a post
+//	@Description	Create a comment, or a reply when parent_id is set
+//	@Tags			Comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Post ID"
+//	@Param			comment	body		comment.Form	true	"Comment body"
+//	@Success		201		{object}	comment.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		422		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/posts/{id}/comments [post]
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	input, ok := h.decodeForm(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), postID, input)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Creating comment failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during creating comment")
+		httpx.Error(w, errors.DBDataInsertFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Created(w, created.ToDto())
+}
+
+// UpdateComment godoc
+//
+//	@Summary		Update comment
+//	@Description	Update the content of a comment, only the author can edit it
+//	@Tags			Comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Comment ID"
+//	@Param			comment	body		comment.Form	true	"Updated comment body"
+//	@Success		200		{object}	comment.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		403		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		422		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/comments/{id} [put]
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	input, ok := h.decodeForm(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.Update(r.Context(), id, input)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Updating comment failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during updating comment")
+		httpx.Error(w, errors.DBDataUpdateFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, updated.ToDto())
+}
+
+// DeleteComment godoc
+//
+//	@Summary		Delete comment
+//	@Description	Soft delete a comment, its replies stay visible
+//	@Tags			Comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Comment ID"
+//	@Success		200	{string}	string	"Deleted message"
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/comments/{id} [delete]
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteById(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Deleting comment failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during deleting comment")
+		httpx.Error(w, errors.DBDataRemoveFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, fmt.Sprintf("Deleted Comment with id `%s`", id))
+}
+
+// decodeForm reads and validates the comment body, it writes the error response itself
+func (h *CommentHandler) decodeForm(w http.ResponseWriter, r *http.Request) (*comment.Form, bool) {
+	input := &comment.Form{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		httpx.Error(w, errors.JSONDecodeFailure, http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	return input, true
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"example.com/goapi/internal/domain/auth"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/httpx"
+)
+
+// Browser clients send `X-Auth-Mode: cookie` on login to keep both tokens in HttpOnly cookies.
+// Afterwards the CSRF cookie marks them, see middleware.CSRF.
+const (
+	authModeHeader = "X-Auth-Mode"
+	authModeCookie = "cookie"
+)
+
+// cookieMode reports whether tokens are returned in cookies instead of the response body
+func (h *AuthHandler) cookieMode(r *http.Request) bool {
+	if r.Header.Get(authModeHeader) == authModeCookie {
+		return true
+	}
+
+	_, err := r.Cookie(m.CSRFTokenCookie)
+	return err == nil
+}
+
+// writeTokens sets the refresh token as HTTP-only cookie and responds with the access token.
+// In cookie mode the access token goes into a cookie as well, along with a new CSRF token.
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, tokens *auth.TokenPair, cookieMode bool) {
+	http.SetCookie(w, h.newCookie(m.RefreshTokenCookie, tokens.RefreshToken, h.conf.RefreshTTL, true))
+
+	if !cookieMode {
+		httpx.Ok(w, map[string]any{
+			"access_token": tokens.AccessToken,
+			"expires_in":   expiresIn(h.conf.AccessTTL),
+		})
+		return
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(b)
+
+	http.SetCookie(w, h.newCookie(m.AccessTokenCookie, tokens.AccessToken, h.conf.AccessTTL, true))
+	// Not HttpOnly, the client script has to read it to send it back in the header
+	http.SetCookie(w, h.newCookie(m.CSRFTokenCookie, csrfToken, h.conf.RefreshTTL, false))
+
+	httpx.Ok(w, map[string]any{
+		"expires_in": expiresIn(h.conf.AccessTTL),
+		"csrf_token": csrfToken,
+	})
+}
+
+// expiresIn formats the lifetime of access tokens as clients always got it, e.g. "15 mins"
+func expiresIn(ttl time.Duration) string {
+	return fmt.Sprintf("%d mins", (ttl+time.Minute-1)/time.Minute)
+}
+
+// clearAuthCookies expires every cookie set by writeTokens
+func (h *AuthHandler) clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.newCookie(m.RefreshTokenCookie, "", -1, true))
+	http.SetCookie(w, h.newCookie(m.AccessTokenCookie, "", -1, true))
+	http.SetCookie(w, h.newCookie(m.CSRFTokenCookie, "", -1, false))
+}
+
+// newCookie creates a cookie with the configured domain, path and security attributes.
+// A negative ttl expires the cookie immediately.
+func (h *AuthHandler) newCookie(name, value string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   h.conf.CookieDomain,
+		Path:     h.conf.CookiePath,
+		HttpOnly: httpOnly,
+		Secure:   h.conf.CookieSecure,
+		SameSite: h.conf.SameSite(),
+		MaxAge:   -1,
+	}
+
+	if ttl > 0 {
+		cookie.Expires = time.Now().Add(ttl)
+		cookie.MaxAge = int(ttl.Seconds()) // This is redundant but can be used together with expires because some browsers still check this attribute
+	}
+
+	return cookie
+}
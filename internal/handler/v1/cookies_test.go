@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/config"
+	"example.com/goapi/internal/domain/auth"
+)
+
+// Clients parse expires_in as it was before the cookie mode, e.g. "15 mins"
+func TestWriteTokensExpiresIn(t *testing.T) {
+	h := NewAuthHandler(nil, nil, nil, &config.ConfAuth{AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour})
+
+	for _, cookieMode := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		h.writeTokens(rec, &auth.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, cookieMode)
+
+		var res struct {
+			Data struct {
+				ExpiresIn any `json:"expires_in"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Data.ExpiresIn != "15 mins" {
+			t.Errorf("cookie mode %v: expires_in = %#v, want \"15 mins\"", cookieMode, res.Data.ExpiresIn)
+		}
+	}
+}
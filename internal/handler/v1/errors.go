@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+)
+
+// apiErrorStatus maps the code of an ApiError to the HTTP status returned to the client
+func apiErrorStatus(apiErr *errors.ApiError) int {
+	switch apiErr.Code {
+	case errors.ErrUnauthorized, errors.ErrInvalidCredentials:
+		return http.StatusUnauthorized
+	case errors.ErrDBNoRows, errors.ErrUserNotFound:
+		return http.StatusNotFound
+	case errors.ErrAuthTokenInvalid, errors.ErrAuthTokenExpired, errors.ErrInvalidRequestBody, errors.ErrPasswordMismatch:
+		return http.StatusBadRequest
+	case errors.ErrUserNotAuthorized:
+		return http.StatusForbidden
+	case errors.ErrUserBlocked, errors.ErrRateLimitExceeded:
+		return http.StatusTooManyRequests
+	case errors.ErrEmailAlreadyUsed, errors.ErrUsernameTaken, errors.ErrDBDuplicateEntry, errors.ErrDBVersionConflict:
+		return http.StatusConflict
+	case errors.ErrFileTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case errors.ErrUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case errors.ErrExternalAPIFailure:
+		return http.StatusBadGateway
+	case errors.ErrFileStorageUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/goapi/internal/domain/post"
+)
+
+// Conditional requests on posts. The ETag is "<version>-<hash>", the hash covers the whole
+// representation, so comments, reactions, the reaction of the viewer and attachments change it
+// as well. If-Match only compares the version, edits conflict with edits but not with reactions.
+
+// setPostETag sets the strong entity tag of the post representation and returns it. The
+// representation depends on the caller, so caches have to keep it apart per credentials.
+func setPostETag(w http.ResponseWriter, dto *post.DTO) string {
+	etag := postETag(dto)
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Add("Vary", "Cookie")
+
+	return etag
+}
+
+// postETag returns the strong entity tag of the post representation
+func postETag(dto *post.DTO) string {
+	body, err := json.Marshal(dto)
+	if err != nil {
+		return `"` + strconv.FormatUint(uint64(dto.Version), 10) + `"`
+	}
+
+	sum := sha256.Sum256(body)
+	return `"` + strconv.FormatUint(uint64(dto.Version), 10) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// parseETagVersion returns the version of a single strong entity tag as sent in If-Match
+func parseETagVersion(etag string) (uint, bool) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+
+	value, _, _ := strings.Cut(etag[1:len(etag)-1], "-")
+	version, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(version), true
+}
+
+// noneMatch reports whether the If-None-Match header of the request matches the entity tag,
+// compared weakly as required for GET requests
+func noneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
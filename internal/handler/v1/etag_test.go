@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/post"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// memoryPosts keeps a single post, unimplemented methods panic
+type memoryPosts struct {
+	post.Service
+	post *post.Post
+}
+
+func (s *memoryPosts) FindById(_ context.Context, id uuid.UUID) (*post.Post, error) {
+	if s.post == nil || s.post.ID != id {
+		return nil, errors.New(errors.ErrDBNoRows, "post not found", nil)
+	}
+
+	found := *s.post
+	return &found, nil
+}
+
+func (s *memoryPosts) Update(_ context.Context, input *post.Post) (*post.Post, error) {
+	if s.post == nil || s.post.ID != input.ID {
+		return nil, errors.New(errors.ErrDBNoRows, "post not found", nil)
+	}
+	if input.Version != s.post.Version {
+		return nil, errors.New(errors.ErrDBVersionConflict, "post was changed in the meantime", nil)
+	}
+
+	s.post.Title, s.post.Content = input.Title, input.Content
+	s.post.Version++
+
+	updated := *s.post
+	return &updated, nil
+}
+
+func newPostsRouter(s post.Service) chi.Router {
+	h := NewHandler(s, validator.New(), nil, nil)
+
+	r := chi.NewRouter()
+	r.Get("/posts/{id}", h.GetPostById)
+	r.Put("/posts/{id}", h.UpdatePostById)
+	return r
+}
+
+func TestPostETagCoversRepresentation(t *testing.T) {
+	p := &post.Post{ID: uuid.New(), Title: "title", Content: "content", Version: 3}
+	r := newPostsRouter(&memoryPosts{post: p})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts/"+p.ID.String(), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"3-`) {
+		t.Fatalf("status = %d, ETag = %s", first.Code, etag)
+	}
+	if vary := first.Header().Values("Vary"); strings.Join(vary, ",") != "Authorization,Cookie" {
+		t.Fatalf("Vary = %v", vary)
+	}
+
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("unchanged post: status = %d, want 304", rec.Code)
+	}
+
+	// Comments and reactions do not bump the version but change the representation
+	p.CommentCount = 1
+	rec := get(etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("new comment: status = %d, want 200", rec.Code)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Fatal("ETag did not change with the comment count")
+	}
+
+	liked := "like"
+	p.ViewerReaction = &liked
+	if rec := get(rec.Header().Get("ETag")); rec.Code != http.StatusOK {
+		t.Fatalf("viewer reaction: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestUpdatePostIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+	}{
+		{"any existing post", "*", http.StatusOK},
+		{"current version", `"3-0123456789abcdef"`, http.StatusOK},
+		{"version only", `"3"`, http.StatusOK},
+		{"outdated version", `"2-0123456789abcdef"`, http.StatusPreconditionFailed},
+		{"not an etag", "3", http.StatusPreconditionFailed},
+		{"missing", "", http.StatusPreconditionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &post.Post{ID: uuid.New(), Title: "title", Content: "content", Version: 3}
+			r := newPostsRouter(&memoryPosts{post: p})
+
+			body := strings.NewReader(`{"title": "new title", "content": "new content"}`)
+			req := httptest.NewRequest(http.MethodPut, "/posts/"+p.ID.String(), body)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+
+	t.Run("any post on a missing one", func(t *testing.T) {
+		r := newPostsRouter(&memoryPosts{})
+
+		body := strings.NewReader(`{"title": "new title", "content": "new content"}`)
+		req := httptest.NewRequest(http.MethodPut, "/posts/"+uuid.NewString(), body)
+		req.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Fatalf("status = %d, want 412: %s", rec.Code, rec.Body)
+		}
+	})
+}
@@ -3,29 +3,32 @@
 package v1
 
 import (
-	"context"
-	"fmt"
 	"net/http"
 
-	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/common/errors"
 	"example.com/goapi/internal/domain/feed"
+	"example.com/goapi/internal/domain/post"
+	m "example.com/goapi/internal/middleware"
 	"example.com/goapi/pkg/httpx"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
 )
 
 type FeedHandler struct {
 	service   feed.Service
 	validator *validator.Validate
+	authn     *m.Authenticator
 }
 
-func NewFeedHandler(s feed.Service, v *validator.Validate) *FeedHandler {
-	return &FeedHandler{service: s, validator: v}
+func NewFeedHandler(s feed.Service, v *validator.Validate, authn *m.Authenticator) *FeedHandler {
+	return &FeedHandler{service: s, validator: v, authn: authn}
 }
 
 // RegisterRoutes
 func (h *FeedHandler) RegisterFeedRoutes(r chi.Router) {
 	r.Route("/feed", func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
 		r.Get("/", h.ListUserFeed)
 	})
 }
@@ -33,45 +36,40 @@ func (h *FeedHandler) RegisterFeedRoutes(r chi.Router) {
 // ListUserFeed godoc
 //
 //	@Summary		Get user's personalized feed
-//	@Description	Returns a paginated list of posts based on user's preferences and filters
+//	@Description	Returns a paginated list of the caller's posts and the posts of the users they follow
 //	@Tags			feed
 //	@Accept			json
 //	@Produce		json
 //	@Param			limit	query		int			false	"Number of items to return (default 20)"
 //	@Param			offset	query		int			false	"Offset for pagination (default 0)"
-//	@Param			sort	query		string		false	"Sort order: 'asc' or 'desc' (default 'desc')"
+//	@Param			sort	query		string		false	"Sort order: 'asc', 'desc' or 'most_reacted' (default 'desc')"
 //	@Param			tags	query		[]string	false	"Filter by tags"
 //	@Param			search	query		string		false	"Search keyword in title/content"
 //	@Success		200		{array}		post.DTO
 //	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		401		{object}	httpx.APIResponse
+//	@Failure		422		{object}	httpx.APIResponse
 //	@Failure		500		{object}	httpx.APIResponse
 //	@Router			/feed [get]
 func (h *FeedHandler) ListUserFeed(w http.ResponseWriter, r *http.Request) {
-	// Pagination and query
-	fq := &query.QueryParams{
-		Limit:  20,
-		Offset: 0,
-		Sort:   "desc",
-		Tags:   []string{},
-		Search: "",
-	}
+	logger := zerolog.Ctx(r.Context())
 
-	fq, err := fq.Parse(r)
-	if err != nil {
-		httpx.Error(w, err.Error(), http.StatusBadRequest)
+	// Pagination and query
+	fq, ok := parseQueryParams(w, r, h.validator, post.SortMostReacted)
+	if !ok {
 		return
 	}
 
-	// Fake user ID for now
-	userIDStr := "22f88b40-3513-4ebe-b4ae-6363dd939455"
-
-	// Add user ID to context
-	ctx := context.WithValue(r.Context(), "user_id", userIDStr)
-
-	// Call service
-	posts, err := h.service.List(ctx, fq)
+	posts, err := h.service.List(r.Context(), fq)
 	if err != nil {
-		httpx.Error(w, fmt.Sprintf("Failed to fetch feed: %s", err), http.StatusInternalServerError)
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Fetching feed failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during fetching feed")
+		httpx.Error(w, "Failed to fetch feed", http.StatusInternalServerError)
 		return
 	}
 
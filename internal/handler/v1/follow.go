@@ -0,0 +1,215 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/follow"
+	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type FollowHandler struct {
+	service   follow.Service
+	validator *validator.Validate
+	authn     *m.Authenticator
+}
+
+func NewFollowHandler(s follow.Service, v *validator.Validate, authn *m.Authenticator) *FollowHandler {
+	return &FollowHandler{service: s, validator: v, authn: authn}
+}
+
+// RegisterFollowRoutes mounts the social graph routes on the given router
+func (h *FollowHandler) RegisterFollowRoutes(r chi.Router) {
+	r.Get("/users/{id}/followers", h.ListFollowers)
+	r.Get("/users/{id}/following", h.ListFollowing)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Post("/users/{id}/follow", h.FollowUser)
+		r.Delete("/users/{id}/follow", h.UnfollowUser)
+	})
+}
+
+// FollowUser godoc
+//
+//	@Summary		Follow a user
+//	@Description	Add the user to the people the caller follows, following twice is a no-op
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	httpx.APIResponse
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/users/{id}/follow [post]
+func (h *FollowHandler) FollowUser(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Follow(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Following user failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during following user")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "User followed"})
+}
+
+// UnfollowUser godoc
+//
+//	@Summary		Unfollow a user
+//	@Description	Remove the user from the people the caller follows
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	httpx.APIResponse
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/users/{id}/follow [delete]
+func (h *FollowHandler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unfollow(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Unfollowing user failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during unfollowing user")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "User unfollowed"})
+}
+
+// ListFollowers godoc
+//
+//	@Summary		List followers
+//	@Description	Get a page of the users following the user and their total count
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"User ID"
+//	@Param			limit	query		int		false	"Number of items to return (default 20)"
+//	@Param			offset	query		int		false	"Offset for pagination (default 0)"
+//	@Success		200		{object}	follow.ListDTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/users/{id}/followers [get]
+func (h *FollowHandler) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	h.listUsers(w, r, h.service.Followers)
+}
+
+// ListFollowing godoc
+//
+//	@Summary		List followed users
+//	@Description	Get a page of the users the user follows and their total count
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"User ID"
+//	@Param			limit	query		int		false	"Number of items to return (default 20)"
+//	@Param			offset	query		int		false	"Offset for pagination (default 0)"
+//	@Success		200		{object}	follow.ListDTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/users/{id}/following [get]
+func (h *FollowHandler) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	h.listUsers(w, r, h.service.Following)
+}
+
+type listFollowsFunc func(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (user.Users, int64, error)
+
+func (h *FollowHandler) listUsers(w http.ResponseWriter, r *http.Request, list listFollowsFunc) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	fq, ok := parseQueryParams(w, r, h.validator)
+	if !ok {
+		return
+	}
+
+	users, count, err := list(r.Context(), id, fq)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing follows failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing follows")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, &follow.ListDTO{Count: count, Users: users.ToDto()})
+}
+
+// parseQueryParams reads the pagination and filters with their defaults, it writes the error response itself.
+// sorts are the orders the endpoint supports on top of asc and desc.
+func parseQueryParams(w http.ResponseWriter, r *http.Request, v *validator.Validate, sorts ...string) (*query.QueryParams, bool) {
+	fq := &query.QueryParams{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+		Tags:   []string{},
+		Search: "",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		httpx.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	// Sorts of the endpoint are known to be valid, the other fields are validated as usual
+	if slices.Contains(sorts, fq.Sort) {
+		err = v.StructExcept(fq, "Sort")
+	} else {
+		err = v.Struct(fq)
+	}
+	if err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	return fq, true
+}
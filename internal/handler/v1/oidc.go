@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/auth"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Name of the cookie keeping the signed state during a login with an external provider
+const oidcStateCookie = "oidc_state"
+
+// StartOIDCLogin handles redirecting the client to the login page of an external provider
+func (h *AuthHandler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authorization, ok := h.startOIDC(w, r, false)
+	if !ok {
+		return
+	}
+
+	http.Redirect(w, r, authorization.URL, http.StatusFound)
+}
+
+// StartOIDCLink handles starting to link an external provider to the caller.
+// The client has to open the returned URL, a redirect would lose the Authorization header.
+func (h *AuthHandler) StartOIDCLink(w http.ResponseWriter, r *http.Request) {
+	authorization, ok := h.startOIDC(w, r, true)
+	if !ok {
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"authorization_url": authorization.URL})
+}
+
+// OIDCCallback handles the redirect back from an external provider, it completes a login or a link
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+	query := r.URL.Query()
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		logger.Warn().Str("error", providerErr).Str("description", query.Get("error_description")).Msg("Identity provider returned an error")
+		httpx.Error(w, "Sign in with identity provider failed: "+providerErr, http.StatusBadRequest)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		httpx.Error(w, "Missing state", http.StatusBadRequest)
+		return
+	}
+
+	// The state is single use, clear it whatever the outcome
+	stateCookie := h.newCookie(oidcStateCookie, "", -1, true)
+	stateCookie.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, stateCookie)
+
+	provider := chi.URLParam(r, "provider")
+	device := deviceFromRequest(r, "")
+	result, err := h.service.FinishOIDC(r.Context(), provider, query.Get("code"), query.Get("state"), cookie.Value, device)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Str("provider", provider).Msg("Sign in with identity provider failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during sign in with identity provider")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if result.Identity != nil {
+		httpx.Ok(w, result.Identity.ToDto())
+		return
+	}
+
+	if result.Login.TwoFactorRequired() {
+		httpx.Ok(w, map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     result.Login.ChallengeToken,
+		})
+		return
+	}
+
+	// The callback is a browser navigation, so the tokens are kept in cookies
+	h.writeTokens(w, result.Login.Tokens, true)
+}
+
+// ListIdentities handles listing the external providers linked to the caller
+func (h *AuthHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	identities, err := h.service.ListIdentities(r.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing identities failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing identities")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]*auth.IdentityDTO, len(identities))
+	for i, identity := range identities {
+		dtos[i] = identity.ToDto()
+	}
+
+	httpx.Ok(w, dtos)
+}
+
+// UnlinkIdentity handles removing an external provider from the caller
+func (h *AuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UnlinkIdentity(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Unlinking identity failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during unlinking identity")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Identity unlinked"})
+}
+
+// startOIDC creates the authorization request and keeps its state in a cookie.
+// NOTE: SameSite must be Lax, the callback is a cross-site navigation from the provider.
+func (h *AuthHandler) startOIDC(w http.ResponseWriter, r *http.Request, link bool) (*auth.OIDCAuthorization, bool) {
+	logger := zerolog.Ctx(r.Context())
+
+	authorization, err := h.service.StartOIDC(r.Context(), chi.URLParam(r, "provider"), link)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Starting sign in with identity provider failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return nil, false
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during starting sign in with identity provider")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	stateCookie := h.newCookie(oidcStateCookie, authorization.State, time.Until(authorization.ExpiresAt), true)
+	stateCookie.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, stateCookie)
+
+	return authorization, true
+}
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"example.com/goapi/pkg/jsonpatch"
+	"github.com/go-playground/validator/v10"
+)
+
+// decodePatch applies the JSON Merge Patch or JSON Patch of the request body to the current
+// form of a resource, decodes the result into target and validates it. It returns the
+// top level fields the patch changed. The error response is written if it fails.
+func decodePatch(w http.ResponseWriter, r *http.Request, v *validator.Validate, current, target any) ([]string, bool) {
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		httpx.Error(w, "content type must be "+jsonpatch.MergePatchType+" or "+jsonpatch.JSONPatchType, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpx.Error(w, "cannot read request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	patched, err := apply(doc, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			httpx.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, jsonpatch.ErrMalformed):
+			httpx.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			httpx.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}
+		return nil, false
+	}
+
+	// The patched document must still be a valid form, unknown fields included
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		httpx.Error(w, "invalid patched document: "+err.Error(), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	if err := v.Struct(target); err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	fields, err := jsonpatch.ChangedMembers(doc, patched)
+	if err != nil {
+		httpx.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	return fields, true
+}
@@ -6,11 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"example.com/goapi/internal/common/errors"
 	"example.com/goapi/internal/database/cache"
 	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/role"
 	m "example.com/goapi/internal/middleware"
 	_v "example.com/goapi/internal/utils/validator"
 	"example.com/goapi/pkg/httpx"
@@ -23,25 +25,37 @@ type Handler struct {
 	service   post.Service
 	validator *validator.Validate
 	redis     *cache.Client
+	authn     *m.Authenticator
 }
 
-func NewHandler(s post.Service, v *validator.Validate, rd *cache.Client) *Handler {
-	return &Handler{service: s, validator: v, redis: rd}
+func NewHandler(s post.Service, v *validator.Validate, rd *cache.Client, authn *m.Authenticator) *Handler {
+	return &Handler{service: s, validator: v, redis: rd, authn: authn}
 }
 
 // RegisterRoutes mounts the post routes on the given router
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/posts", func(r chi.Router) {
-		r.Get("/", h.ListAllPosts)
-		r.Get("/{id}", h.GetPostById)
+		// Public, the caller's own reactions are shown when authenticated
+		r.With(h.authn.OptionalAuthenticate).Get("/", h.ListAllPosts)
+		r.With(h.authn.OptionalAuthenticate).Get("/{id}", h.GetPostById)
 
 		r.Group(func(r chi.Router) {
-			r.Use(m.Authenticate())
+			r.Use(h.authn.Authenticate)
 			r.With(m.AllowAccess([]string{"user", "admin"})).Post("/", h.CreatePost)
 			r.Put("/{id}", h.UpdatePostById)
+			r.Patch("/{id}", h.PatchPostById)
 			r.Delete("/{id}", h.DeletePostBy)
+			r.Post("/{id}/restore", h.RestorePostById)
 		})
 	})
+
+	r.With(h.authn.Authenticate).Get("/users/me/trash", h.ListTrash)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Use(m.RequirePermission(role.PostsDeleteAny))
+		r.Delete("/admin/posts/{id}", h.HardDeletePostById)
+	})
 }
 
 // ListAllPosts godoc
@@ -55,33 +69,18 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 //	@Param			q		query		string		false	"Search query"
 //	@Param			tags	query		[]string	false	"Tags filter (a,b,c means OR)"
 //	@Param			title	query		string		false	"Exact title match"
+//	@Param			sort	query		string		false	"Sort order: 'asc', 'desc' or 'most_reacted'"
 //	@Success		200		{array}		post.DTO
+//	@Failure		422		{object}	httpx.APIResponse
 //	@Failure		500		{object}	httpx.APIResponse
 //	@Router			/posts [get]
 func (h *Handler) ListAllPosts(w http.ResponseWriter, r *http.Request) {
-	// Generate a cache key based on all query parameters
-	cacheKey := fmt.Sprintf("posts:%s:%s:%v:%s",
-		r.URL.Query().Get("user_id"),
-		r.URL.Query().Get("q"),
-		r.URL.Query()["tags"],
-		r.URL.Query().Get("title"),
-	)
-
-	// Try to get from cache first
-	cachedData, err := h.redis.Get(r.Context(), cacheKey).Bytes()
-	if err == nil {
-		var posts post.Posts
-		err = json.Unmarshal(cachedData, &posts)
-		if err != nil {
-			httpx.Error(w, fmt.Sprintf("Error unmarshalling cache data: %s", err), http.StatusInternalServerError)
-			return
-		}
-
-		httpx.Ok(w, posts)
-		return
+	// The response contains the caller's reactions, so it is cached per viewer
+	viewerID := uuid.Nil
+	if userData, ok := m.GetUserDetailsFromContext(r.Context()); ok {
+		viewerID = userData.UserID
 	}
 
-	// Cache miss - proceed with normal processing
 	uID := uuid.Nil
 	if idx := r.URL.Query().Get("user_id"); idx != "" {
 		uID = uuid.MustParse(idx)
@@ -92,8 +91,40 @@ func (h *Handler) ListAllPosts(w http.ResponseWriter, r *http.Request) {
 		Tags:   r.URL.Query()["tags"],
 		Title:  r.URL.Query().Get("title"),
 		UserID: uID,
+		Sort:   r.URL.Query().Get("sort"),
 	}
 
+	if err := h.validator.Struct(query); err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Generate a cache key based on all query parameters, the cache is skipped without one
+	cacheKey, keyErr := h.redis.PostListKey(r.Context(), fmt.Sprintf("%s:%s:%v:%s:%s:%s",
+		r.URL.Query().Get("user_id"),
+		r.URL.Query().Get("q"),
+		r.URL.Query()["tags"],
+		r.URL.Query().Get("title"),
+		r.URL.Query().Get("sort"),
+		viewerID,
+	))
+
+	// Try to get from cache first
+	if keyErr == nil {
+		if cachedData, err := h.redis.Get(r.Context(), cacheKey).Bytes(); err == nil {
+			var posts []*post.DTO
+			err = json.Unmarshal(cachedData, &posts)
+			if err != nil {
+				httpx.Error(w, fmt.Sprintf("Error unmarshalling cache data: %s", err), http.StatusInternalServerError)
+				return
+			}
+
+			httpx.Ok(w, posts)
+			return
+		}
+	}
+
+	// Cache miss - proceed with normal processing
 	posts, err := h.service.FindAll(r.Context(), query)
 	if err != nil {
 		httpx.Error(w, fmt.Sprintf("Error: %s", err), http.StatusInternalServerError)
@@ -103,7 +134,7 @@ func (h *Handler) ListAllPosts(w http.ResponseWriter, r *http.Request) {
 	// Convert to DTO and cache the result
 	postsDto := posts.ToDto()
 	jsonData, err := json.Marshal(postsDto)
-	if err == nil {
+	if err == nil && keyErr == nil {
 		// Cache for 5 minutes (adjust TTL as needed)
 		h.redis.Set(r.Context(), cacheKey, jsonData, 5*time.Minute)
 	}
@@ -139,11 +170,18 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.service.Create(r.Context(), input)
 	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok && apiErr.Code == errors.ErrInvalidRequestBody {
+			httpx.Error(w, apiErr.Message, http.StatusBadRequest)
+			return
+		}
+
 		httpx.Error(w, errors.DBDataInsertFailure, http.StatusInternalServerError)
 		return
 	}
 
-	httpx.Created(w, post.ToDto())
+	dto := post.ToDto()
+	setPostETag(w, dto)
+	httpx.Created(w, dto)
 }
 
 // GetPostById godoc
@@ -153,10 +191,13 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 //	@Tags			Posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"Post ID"
-//	@Success		200	{object}	post.DTO
-//	@Failure		400	{object}	httpx.APIResponse
-//	@Failure		500	{object}	httpx.APIResponse
+//	@Param			id				path		string	true	"Post ID"
+//	@Param			If-None-Match	header		string	false	"ETag of a cached version"
+//	@Success		200				{object}	post.DTO
+//	@Success		304				{string}	string	"Not modified"
+//	@Failure		400				{object}	httpx.APIResponse
+//	@Failure		404				{object}	httpx.APIResponse
+//	@Failure		500				{object}	httpx.APIResponse
 //	@Router			/posts/{id} [get]
 func (h *Handler) GetPostById(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -167,11 +208,22 @@ func (h *Handler) GetPostById(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.service.FindById(r.Context(), id)
 	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
 		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
 		return
 	}
 
-	httpx.Ok(w, post.ToDto())
+	dto := post.ToDto()
+	if noneMatch(r, setPostETag(w, dto)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	httpx.Ok(w, dto)
 }
 
 // UpdatePostById godoc
@@ -181,12 +233,18 @@ func (h *Handler) GetPostById(w http.ResponseWriter, r *http.Request) {
 //	@Tags			Posts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		string		true	"Post ID"
-//	@Param			post	body		post.Form	true	"Updated post body"
-//	@Success		200		{object}	post.DTO
-//	@Failure		400		{object}	httpx.APIResponse
-//	@Failure		422		{object}	httpx.APIResponse
-//	@Failure		500		{object}	httpx.APIResponse
+//	@Param			id			path		string		true	"Post ID"
+//	@Param			If-Match	header		string		false	"ETag of the version the update is based on, * for the current version, or the version field"
+//	@Param			post		body		post.Form	true	"Updated post body"
+//	@Success		200			{object}	post.DTO
+//	@Failure		400			{object}	httpx.APIResponse
+//	@Failure		403			{object}	httpx.APIResponse
+//	@Failure		404			{object}	httpx.APIResponse
+//	@Failure		409			{object}	httpx.APIResponse	"Version mismatch, with the current post"
+//	@Failure		412			{object}	httpx.APIResponse	"If-Match mismatch, with the current post"
+//	@Failure		422			{object}	httpx.APIResponse
+//	@Failure		428			{object}	httpx.APIResponse
+//	@Failure		500			{object}	httpx.APIResponse
 //	@Router			/posts/{id} [put]
 func (h *Handler) UpdatePostById(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -201,16 +259,167 @@ func (h *Handler) UpdatePostById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Updates must name the version they are based on, so concurrent edits are not lost.
+	// If-Match: * matches any existing post, the update is based on the current version.
+	version, fromHeader := input.Version, false
+	switch ifMatch := strings.TrimSpace(r.Header.Get("If-Match")); ifMatch {
+	case "":
+	case "*":
+		current, err := h.service.FindById(r.Context(), id)
+		if err != nil {
+			if apiErr, ok := err.(*errors.ApiError); ok {
+				status := apiErrorStatus(apiErr)
+				if status == http.StatusNotFound {
+					status = http.StatusPreconditionFailed
+				}
+				httpx.Error(w, apiErr.Message, status)
+				return
+			}
+
+			httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+			return
+		}
+		version, fromHeader = &current.Version, true
+	default:
+		parsed, ok := parseETagVersion(ifMatch)
+		if !ok {
+			httpx.Error(w, "If-Match must be a single ETag of the post", http.StatusPreconditionFailed)
+			return
+		}
+		version, fromHeader = &parsed, true
+	}
+
+	if version == nil {
+		httpx.Error(w, "If-Match header or version is required", http.StatusPreconditionRequired)
+		return
+	}
+
 	post := input.ToModel()
 	post.ID = id
+	post.Version = *version
 
 	created, err := h.service.Update(r.Context(), post)
 	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			if apiErr.Code == errors.ErrDBVersionConflict {
+				h.writeVersionConflict(w, r, id, apiErr, fromHeader)
+				return
+			}
+
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	dto := created.ToDto()
+	setPostETag(w, dto)
+	httpx.Ok(w, dto)
+}
+
+// PatchPostById godoc
+//
+//	@Summary		Patch post
+//	@Description	Partially update a post with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) applied to post.Form, only the changed fields are written
+//	@Tags			Posts
+//	@Accept			application/merge-patch+json,application/json-patch+json
+//	@Produce		json
+//	@Param			id			path		string		true	"Post ID"
+//	@Param			If-Match	header		string		false	"ETag of the version the patch is based on"
+//	@Param			patch		body		post.Form	true	"Merge patch, or an array of JSON Patch operations"
+//	@Success		200			{object}	post.DTO
+//	@Failure		400			{object}	httpx.APIResponse
+//	@Failure		403			{object}	httpx.APIResponse
+//	@Failure		404			{object}	httpx.APIResponse
+//	@Failure		409			{object}	httpx.APIResponse	"Failed test operation or version mismatch"
+//	@Failure		412			{object}	httpx.APIResponse	"If-Match mismatch, with the current post"
+//	@Failure		415			{object}	httpx.APIResponse
+//	@Failure		422			{object}	httpx.APIResponse
+//	@Failure		500			{object}	httpx.APIResponse
+//	@Router			/posts/{id} [patch]
+func (h *Handler) PatchPostById(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.service.FindById(r.Context(), id)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+		return
+	}
+
+	input := &post.Form{}
+	fields, ok := decodePatch(w, r, h.validator, current.ToForm(), input)
+	if !ok {
+		return
+	}
+
+	// The patch is based on the version it was applied to, unless If-Match or the
+	// version field of the patched form name another one
+	version, fromHeader := current.Version, false
+	if input.Version != nil {
+		version = *input.Version
+	}
+	if ifMatch := strings.TrimSpace(r.Header.Get("If-Match")); ifMatch != "" && ifMatch != "*" {
+		parsed, ok := parseETagVersion(ifMatch)
+		if !ok {
+			httpx.Error(w, "If-Match must be a single ETag of the post", http.StatusPreconditionFailed)
+			return
+		}
+		version, fromHeader = parsed, true
+	}
+
+	post := input.ToModel()
+	post.ID = id
+	post.Version = version
+
+	patched, err := h.service.Patch(r.Context(), post, fields)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			if apiErr.Code == errors.ErrDBVersionConflict {
+				h.writeVersionConflict(w, r, id, apiErr, fromHeader)
+				return
+			}
+
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
 		httpx.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	httpx.Ok(w, created.ToDto())
+	dto := patched.ToDto()
+	setPostETag(w, dto)
+	httpx.Ok(w, dto)
+}
+
+// writeVersionConflict responds with the current post, so the client can merge its changes.
+// A failed If-Match header is answered with 412, an outdated version field with 409.
+func (h *Handler) writeVersionConflict(w http.ResponseWriter, r *http.Request, id uuid.UUID, apiErr *errors.ApiError, fromHeader bool) {
+	status := http.StatusConflict
+	if fromHeader {
+		status = http.StatusPreconditionFailed
+	}
+
+	current, err := h.service.FindById(r.Context(), id)
+	if err != nil {
+		httpx.Error(w, apiErr.Message, status)
+		return
+	}
+
+	dto := current.ToDto()
+	setPostETag(w, dto)
+	httpx.ErrorWithData(w, apiErr.Message, dto, status)
 }
 
 // DeletePostBy godoc
@@ -223,6 +432,8 @@ func (h *Handler) UpdatePostById(w http.ResponseWriter, r *http.Request) {
 //	@Param			id	path		string	true	"Post ID"
 //	@Success		200	{string}	string	"Deleted message"
 //	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
 //	@Failure		500	{object}	httpx.APIResponse
 //	@Router			/posts/{id} [delete]
 func (h *Handler) DeletePostBy(w http.ResponseWriter, r *http.Request) {
@@ -234,9 +445,119 @@ func (h *Handler) DeletePostBy(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.DeleteById(r.Context(), id)
 	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
 		httpx.Error(w, errors.DBDataRemoveFailure, http.StatusInternalServerError)
 		return
 	}
 
 	httpx.Ok(w, fmt.Sprintf("Deleted Post with id `%s`", id))
 }
+
+// ListTrash godoc
+//
+//	@Summary		List deleted posts
+//	@Description	Get the deleted posts of the caller, most recently deleted first. They are purged after the retention period.
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"
+//	@Param			offset	query		int	false	"Offset"
+//	@Success		200		{array}		post.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		422		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/users/me/trash [get]
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	fq, ok := parseQueryParams(w, r, h.validator)
+	if !ok {
+		return
+	}
+
+	posts, err := h.service.Trash(r.Context(), fq)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, posts.ToDto())
+}
+
+// RestorePostById godoc
+//
+//	@Summary		Restore post
+//	@Description	Take a deleted post out of the trash
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{object}	post.DTO
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/posts/{id}/restore [post]
+func (h *Handler) RestorePostById(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, errors.DBDataUpdateFailure, http.StatusInternalServerError)
+		return
+	}
+
+	dto := post.ToDto()
+	setPostETag(w, dto)
+	httpx.Ok(w, dto)
+}
+
+// HardDeletePostById godoc
+//
+//	@Summary		Purge post
+//	@Description	Permanently remove a post with its comments, reactions, bookmarks and revisions, deleted or not (admin only)
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{string}	string	"Deleted message"
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/admin/posts/{id} [delete]
+func (h *Handler) HardDeletePostById(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.HardDelete(r.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, errors.DBDataRemoveFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, fmt.Sprintf("Purged Post with id `%s`", id))
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/goapi/internal/domain/post"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestParseQueryParamsSorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		sorts  []string
+		status int
+	}{
+		{"default sort", "/?sort=asc", nil, http.StatusOK},
+		{"post sort on a post list", "/?sort=most_reacted", []string{post.SortMostReacted}, http.StatusOK},
+		{"post sort elsewhere", "/?sort=most_reacted", nil, http.StatusUnprocessableEntity},
+		{"unknown sort", "/?sort=newest", []string{post.SortMostReacted}, http.StatusUnprocessableEntity},
+		{"other fields still validated", "/?sort=most_reacted&limit=50", []string{post.SortMostReacted}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			fq, ok := parseQueryParams(rec, httptest.NewRequest(http.MethodGet, tt.url, nil), validator.New(), tt.sorts...)
+
+			if tt.status == http.StatusOK {
+				if !ok || fq == nil {
+					t.Fatalf("rejected: %d %s", rec.Code, rec.Body)
+				}
+				return
+			}
+			if ok || rec.Code != tt.status {
+				t.Fatalf("ok = %v, status = %d, want %d", ok, rec.Code, tt.status)
+			}
+		})
+	}
+}
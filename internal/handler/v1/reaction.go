@@ -0,0 +1,126 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/reaction"
+	m "example.com/goapi/internal/middleware"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type ReactionHandler struct {
+	service   reaction.Service
+	validator *validator.Validate
+	authn     *m.Authenticator
+}
+
+func NewReactionHandler(s reaction.Service, v *validator.Validate, authn *m.Authenticator) *ReactionHandler {
+	return &ReactionHandler{service: s, validator: v, authn: authn}
+}
+
+// RegisterReactionRoutes mounts the reaction routes on the given router
+func (h *ReactionHandler) RegisterReactionRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Put("/posts/{id}/reaction", h.React)
+		r.Delete("/posts/{id}/reaction", h.Unreact)
+	})
+}
+
+// React godoc
+//
+//	@Summary		React to a post
+//	@Description	Set the reaction of the caller, it replaces a reaction of another kind
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string			true	"Post ID"
+//	@Param			reaction	body		reaction.Form	true	"Reaction kind"
+//	@Success		200			{object}	reaction.SummaryDTO
+//	@Failure		400			{object}	httpx.APIResponse
+//	@Failure		404			{object}	httpx.APIResponse
+//	@Failure		422			{object}	httpx.APIResponse
+//	@Failure		500			{object}	httpx.APIResponse
+//	@Router			/posts/{id}/reaction [put]
+func (h *ReactionHandler) React(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	input := &reaction.Form{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		httpx.Error(w, errors.JSONDecodeFailure, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		httpx.Errors(w, _v.ToErrResponse(err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	summary, err := h.service.React(r.Context(), postID, input.Kind)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Reacting to post failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during reacting to post")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, summary)
+}
+
+// Unreact godoc
+//
+//	@Summary		Remove reaction
+//	@Description	Remove the reaction of the caller from a post
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{object}	reaction.SummaryDTO
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/posts/{id}/reaction [delete]
+func (h *ReactionHandler) Unreact(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.service.Unreact(r.Context(), postID)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Removing reaction failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during removing reaction")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, summary)
+}
@@ -0,0 +1,227 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/revision"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type RevisionHandler struct {
+	service revision.Service
+	authn   *m.Authenticator
+}
+
+func NewRevisionHandler(s revision.Service, authn *m.Authenticator) *RevisionHandler {
+	return &RevisionHandler{service: s, authn: authn}
+}
+
+// RegisterRevisionRoutes mounts the revision routes on the given router
+func (h *RevisionHandler) RegisterRevisionRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		// Owners can see the history of their unpublished posts
+		r.Use(h.authn.OptionalAuthenticate)
+		r.Get("/posts/{id}/revisions", h.ListRevisions)
+		r.Get("/posts/{id}/revisions/diff", h.DiffRevisions)
+		r.Get("/posts/{id}/revisions/{version}", h.GetRevision)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Post("/posts/{id}/revisions/{version}/restore", h.RestoreRevision)
+	})
+}
+
+// ListRevisions godoc
+//
+//	@Summary		List revisions
+//	@Description	Get the versions of a post newest first, starting with the current one
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Post ID"
+//	@Success		200	{array}		revision.DTO
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		404	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/posts/{id}/revisions [get]
+func (h *RevisionHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.List(r.Context(), postID)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Listing revisions failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during listing revisions")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, revisions.ToListDto())
+}
+
+// GetRevision godoc
+//
+//	@Summary		Get revision
+//	@Description	Get a version of a post with its content
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Post ID"
+//	@Param			version	path		int		true	"Version"
+//	@Success		200		{object}	revision.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/posts/{id}/revisions/{version} [get]
+func (h *RevisionHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseVersion(chi.URLParam(r, "version"))
+	if err != nil {
+		httpx.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+
+	rev, err := h.service.Get(r.Context(), postID, version)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Getting revision failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during getting revision")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, rev.ToDto())
+}
+
+// DiffRevisions godoc
+//
+//	@Summary		Diff revisions
+//	@Description	Get the line based changes of the title and content between two versions of a post
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Post ID"
+//	@Param			from	query		int		true	"Old version"
+//	@Param			to		query		int		true	"New version"
+//	@Success		200		{object}	revision.DiffDTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/posts/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseVersion(r.URL.Query().Get("from"))
+	if err != nil {
+		httpx.Error(w, "invalid from version", http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseVersion(r.URL.Query().Get("to"))
+	if err != nil {
+		httpx.Error(w, "invalid to version", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.service.Diff(r.Context(), postID, from, to)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Diffing revisions failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during diffing revisions")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, diff)
+}
+
+// RestoreRevision godoc
+//
+//	@Summary		Restore revision
+//	@Description	Write the content of an old version as a new version of the post
+//	@Tags			Posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Post ID"
+//	@Param			version	path		int		true	"Version"
+//	@Success		200		{object}	post.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		403		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/posts/{id}/revisions/{version}/restore [post]
+func (h *RevisionHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseVersion(chi.URLParam(r, "version"))
+	if err != nil {
+		httpx.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := h.service.Restore(r.Context(), postID, version)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Restoring revision failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during restoring revision")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, restored.ToDto())
+}
+
+func parseVersion(s string) (uint, error) {
+	version, err := strconv.ParseUint(s, 10, 32)
+	return uint(version), err
+}
@@ -0,0 +1,184 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/role"
+	m "example.com/goapi/internal/middleware"
+	_v "example.com/goapi/internal/utils/validator"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type RoleHandler struct {
+	service   role.Service
+	validator *validator.Validate
+	authn     *m.Authenticator
+}
+
+func NewRoleHandler(s role.Service, v *validator.Validate, authn *m.Authenticator) *RoleHandler {
+	return &RoleHandler{service: s, validator: v, authn: authn}
+}
+
+// RegisterRoleRoutes mounts the admin role routes on the given router
+func (h *RoleHandler) RegisterRoleRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.authn.Authenticate)
+		r.Use(m.RequirePermission(role.RolesManage))
+
+		r.Get("/admin/roles", h.ListRoles)
+		r.Get("/admin/users/{id}/roles", h.ListUserRoles)
+		r.Post("/admin/users/{id}/roles", h.GrantRole)
+		r.Delete("/admin/users/{id}/roles/{role}", h.RevokeRole)
+	})
+}
+
+// ListRoles godoc
+//
+//	@Summary		List roles
+//	@Description	Get all roles with their permissions
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		role.DTO
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/admin/roles [get]
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	roles, err := h.service.List(r.Context())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch roles")
+		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, roles.ToDto())
+}
+
+// ListUserRoles godoc
+//
+//	@Summary		List roles of a user
+//	@Description	Get the roles granted to a user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{array}		role.DTO
+//	@Failure		400	{object}	httpx.APIResponse
+//	@Failure		403	{object}	httpx.APIResponse
+//	@Failure		500	{object}	httpx.APIResponse
+//	@Router			/admin/users/{id}/roles [get]
+func (h *RoleHandler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	roles, err := h.service.UserRoles(r.Context(), id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch user roles")
+		httpx.Error(w, errors.DBDataAccessFailure, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, roles.ToDto())
+}
+
+// GrantRole godoc
+//
+//	@Summary		Grant a role
+//	@Description	Grant a role to a user. Granting a role twice is a no-op
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"User ID"
+//	@Param			role	body		role.GrantForm	true	"Role to grant"
+//	@Success		200		{string}	string			"Granted message"
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		403		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/admin/users/{id}/roles [post]
+func (h *RoleHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	input := &role.GrantForm{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		httpx.Error(w, errors.JSONDecodeFailure, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		respBody := _v.ToErrResponse(err)
+		httpx.Errors(w, respBody, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.service.Grant(r.Context(), id, input.Role); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Granting role failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during granting role")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Role granted"})
+}
+
+// RevokeRole godoc
+//
+//	@Summary		Revoke a role
+//	@Description	Revoke a role from a user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id		path		string	true	"User ID"
+//	@Param			role	path		string	true	"Role name"
+//	@Success		200		{string}	string	"Revoked message"
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		403		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/admin/users/{id}/roles/{role} [delete]
+func (h *RoleHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), id, chi.URLParam(r, "role")); err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Revoking role failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during revoking role")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "Role revoked"})
+}
@@ -0,0 +1,139 @@
+//	@BasePath	/api/v1
+
+package v1
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/upload"
+	m "example.com/goapi/internal/middleware"
+	"example.com/goapi/pkg/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// Room for the multipart boundaries and headers around the file
+const multipartOverhead = 1 << 20
+
+type UploadHandler struct {
+	service upload.Service
+	authn   *m.Authenticator
+	maxSize int64
+}
+
+func NewUploadHandler(s upload.Service, authn *m.Authenticator, maxSize int64) *UploadHandler {
+	return &UploadHandler{service: s, authn: authn, maxSize: maxSize}
+}
+
+// RegisterUploadRoutes mounts the upload routes on the given router
+func (h *UploadHandler) RegisterUploadRoutes(r chi.Router) {
+	r.With(h.authn.Authenticate).Post("/uploads", h.CreateUpload)
+}
+
+// CreateUpload godoc
+//
+//	@Summary		Upload a file
+//	@Description	Upload a file to attach to posts. The type is detected from the content and must be allowed.
+//	@Description	Uploading the same file again returns the existing upload with 200.
+//	@Tags			Uploads
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file	true	"File to upload"
+//	@Success		201		{object}	httpx.APIResponse{data=upload.DTO}
+//	@Success		200		{object}	httpx.APIResponse{data=upload.DTO}	"Already uploaded"
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		413		{object}	httpx.APIResponse
+//	@Failure		415		{object}	httpx.APIResponse
+//	@Failure		503		{object}	httpx.APIResponse
+//	@Router			/uploads [post]
+func (h *UploadHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		httpx.Error(w, "content type must be multipart/form-data", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	file, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create temporary upload file")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	size, err := h.spoolFile(reader, file)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) || err == errFileTooLarge {
+			httpx.Error(w, fmt.Sprintf("file must not be larger than %d bytes", h.maxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		httpx.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, created, err := h.service.Upload(r.Context(), file, size)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Uploading file failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during uploading file")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !created {
+		httpx.Ok(w, u.ToDto())
+		return
+	}
+
+	httpx.Created(w, u.ToDto())
+}
+
+var errFileTooLarge = stderrors.New("file too large")
+
+// spoolFile copies the "file" part of the form into file, so it can be read more than
+// once without keeping it in memory
+func (h *UploadHandler) spoolFile(reader *multipart.Reader, file *os.File) (int64, error) {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return 0, stderrors.New("missing file")
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		size, err := io.Copy(file, io.LimitReader(part, h.maxSize+1))
+		if err != nil {
+			return 0, err
+		}
+
+		if size > h.maxSize {
+			return 0, errFileTooLarge
+		}
+
+		return size, nil
+	}
+}
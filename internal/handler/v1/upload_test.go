@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/domain/upload"
+	m "example.com/goapi/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// failingUploads rejects every upload with err
+type failingUploads struct {
+	err error
+}
+
+func (s failingUploads) Upload(context.Context, io.ReadSeeker, int64) (*upload.Upload, bool, error) {
+	return nil, false, s.err
+}
+
+// anyKey resolves every API key to a user allowed to upload
+type anyKey struct{}
+
+func (anyKey) VerifyAPIKey(context.Context, string) (*m.UserDataContext, error) {
+	return &m.UserDataContext{UserID: uuid.New(), APIKeyID: uuid.New()}, nil
+}
+
+func TestCreateUploadErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"type not allowed", errors.New(errors.ErrUnsupportedMediaType, "file type 'text/plain' is not allowed", nil), http.StatusUnsupportedMediaType},
+		{"too large", errors.New(errors.ErrFileTooLarge, "file must not be larger than 1024 bytes", nil), http.StatusRequestEntityTooLarge},
+		{"storage down", errors.New(errors.ErrFileStorageUnavailable, "cannot store file", nil), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authn := m.NewAuthenticator(m.AuthOptions{APIKeys: anyKey{}})
+			r := chi.NewRouter()
+			NewUploadHandler(failingUploads{err: tt.err}, authn, 1<<10).RegisterUploadRoutes(r)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile("file", "image.png")
+			part.Write([]byte("content"))
+			form.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/uploads", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			req.Header.Set("Authorization", "ApiKey goapi_abc_secret")
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"example.com/goapi/internal/common/errors"
 	"example.com/goapi/internal/domain/user"
+	m "example.com/goapi/internal/middleware"
 	_v "example.com/goapi/internal/utils/validator"
 	"example.com/goapi/pkg/httpx"
 	"github.com/go-chi/chi/v5"
@@ -20,10 +21,11 @@ import (
 type UserHandler struct {
 	service   user.Service
 	validator *validator.Validate
+	authn     *m.Authenticator
 }
 
-func NewUserHandler(s user.Service, v *validator.Validate) *UserHandler {
-	return &UserHandler{service: s, validator: v}
+func NewUserHandler(s user.Service, v *validator.Validate, authn *m.Authenticator) *UserHandler {
+	return &UserHandler{service: s, validator: v, authn: authn}
 }
 
 // RegisterRoutes mounts the post routes on the given router
@@ -32,8 +34,14 @@ func (h *UserHandler) RegisterUserRoutes(r chi.Router) {
 		r.Get("/", h.ListAllUsers)
 		r.Post("/", h.CreateUser)
 		r.Get("/{id}", h.GetUserById)
-		r.Put("/{id}", h.UpdateUserById)
 		r.Delete("/{id}", h.DeleteUserById)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.authn.Authenticate)
+			r.Use(m.RequireInteractiveSession)
+			r.Put("/{id}", h.UpdateUserById)
+			r.Patch("/{id}", h.PatchUserById)
+		})
 	})
 }
 
@@ -159,6 +167,66 @@ func (h *UserHandler) UpdateUserById(w http.ResponseWriter, r *http.Request) {
 	httpx.Ok(w, "UpdateUserById handler")
 }
 
+// PatchUserById godoc
+//
+//	@Summary		Patch user by ID
+//	@Description	Partially update a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) applied to user.UpdateForm, only the changed fields are written
+//	@Tags			users
+//	@Accept			application/merge-patch+json,application/json-patch+json
+//	@Produce		json
+//	@Param			id		path		string			true	"User ID"
+//	@Param			patch	body		user.UpdateForm	true	"Merge patch, or an array of JSON Patch operations"
+//	@Success		200		{object}	user.DTO
+//	@Failure		400		{object}	httpx.APIResponse
+//	@Failure		403		{object}	httpx.APIResponse
+//	@Failure		404		{object}	httpx.APIResponse
+//	@Failure		409		{object}	httpx.APIResponse
+//	@Failure		415		{object}	httpx.APIResponse
+//	@Failure		422		{object}	httpx.APIResponse
+//	@Failure		500		{object}	httpx.APIResponse
+//	@Router			/users/{id} [patch]
+func (h *UserHandler) PatchUserById(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.Error(w, errors.InvalidURLParamID, http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	input := &user.UpdateForm{}
+	fields, ok := decodePatch(w, r, h.validator, current.ToUpdateForm(), input)
+	if !ok {
+		return
+	}
+
+	patched, err := h.service.Patch(r.Context(), id, input, fields)
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			logger.Error().Err(err).Msg("Patching user failed")
+			httpx.Error(w, apiErr.Message, apiErrorStatus(apiErr))
+			return
+		}
+
+		logger.Error().Err(err).Msg("Unexpected error during patching user")
+		httpx.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, patched.ToDto())
+}
+
 // DeleteUserById godoc
 //
 //	@Summary		Delete user by ID
@@ -0,0 +1,236 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/goapi/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Supported signing algorithms
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+	HS256 = "HS256"
+)
+
+// Key is a single signing or verification key
+type Key struct {
+	ID        string
+	Algorithm string
+
+	signKey   any // *rsa.PrivateKey, ed25519.PrivateKey or []byte for HS256
+	verifyKey any // *rsa.PublicKey, ed25519.PublicKey or []byte for HS256
+}
+
+// KeySet signs access tokens with the active key and verifies them with any known key.
+type KeySet struct {
+	signing   *Key
+	keys      map[string]*Key
+	ephemeral bool
+}
+
+// New loads the keys described by cfg.
+// NOTE: When no asymmetric key is configured a random one is generated, tokens then
+// don't survive restarts and can't be verified by other instances. Only use this for development.
+func New(cfg *config.ConfJWT) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+
+	signing, err := loadSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if signing == nil {
+		signing, err = generateKey(cfg.Algorithm, cfg.KeyID)
+		if err != nil {
+			return nil, err
+		}
+
+		ks.ephemeral = true
+		log.Warn().Str("kid", signing.ID).Msg("No JWT signing key configured, using a generated key")
+	}
+
+	ks.signing = signing
+	ks.keys[signing.ID] = signing
+
+	for _, entry := range cfg.VerifyKeyFiles {
+		key, err := loadVerifyKey(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := ks.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	return ks, nil
+}
+
+// Sign signs the claims with the active key and sets the `kid` header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.signing.Algorithm), claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Keyfunc resolves the verification key of a token from its `kid` header
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	// Never let the token choose a different algorithm than the one of the key
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+
+	return key.verifyKey, nil
+}
+
+// Algorithms returns the algorithms of all known keys
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	algs := []string{}
+	for _, key := range ks.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	return algs
+}
+
+// SigningKeyID returns the id of the active signing key
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.ID
+}
+
+// Ephemeral reports whether the signing key was generated at startup
+func (ks *KeySet) Ephemeral() bool {
+	return ks.ephemeral
+}
+
+func loadSigningKey(cfg *config.ConfJWT) (*Key, error) {
+	if cfg.Algorithm == HS256 {
+		if cfg.HMACSecret == "" {
+			return nil, fmt.Errorf("JWT_HMAC_SECRET is required for %s", HS256)
+		}
+
+		kid := cfg.KeyID
+		if kid == "" {
+			kid = "hs256"
+		}
+
+		secret := []byte(cfg.HMACSecret)
+		return &Key{ID: kid, Algorithm: HS256, signKey: secret, verifyKey: secret}, nil
+	}
+
+	data := []byte(strings.ReplaceAll(cfg.PrivateKey, `\n`, "\n"))
+	if cfg.PrivateKeyFile != "" {
+		var err error
+		if data, err = os.ReadFile(cfg.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	private, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newKey(cfg.KeyID, private, private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Algorithm != cfg.Algorithm {
+		return nil, fmt.Errorf("JWT private key is not a %s key", cfg.Algorithm)
+	}
+
+	return key, nil
+}
+
+// loadVerifyKey loads a verification key from an entry of the form `kid=path` or `path`.
+// Without an explicit kid, the file name without extension is used.
+func loadVerifyKey(entry string) (*Key, error) {
+	kid, path, ok := strings.Cut(entry, "=")
+	if !ok {
+		path = entry
+		kid = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT verification key: %w", err)
+	}
+
+	public, err := parsePublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKey(kid, nil, public)
+}
+
+func newKey(kid string, private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	key := &Key{ID: kid, verifyKey: public}
+	if private != nil {
+		key.signKey = private
+	}
+
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.Algorithm = RS256
+	case ed25519.PublicKey:
+		key.Algorithm = EdDSA
+	default:
+		return nil, fmt.Errorf("unsupported JWT key type %T", public)
+	}
+
+	if key.ID == "" {
+		thumbprint, err := Thumbprint(public)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = thumbprint
+	}
+
+	return key, nil
+}
+
+func generateKey(algorithm, kid string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JWT key: %w", err)
+	}
+
+	return newKey(kid, private, private.Public())
+}
@@ -0,0 +1,197 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/goapi/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newPrivateKey returns a PKCS#8 PEM encoded Ed25519 key
+func newPrivateKey(t *testing.T) (string, crypto.Signer) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), private
+}
+
+// writePublicKey stores the public part of the key as a PEM file and returns its path
+func writePublicKey(t *testing.T, name string, private crypto.Signer) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newKeySet(t *testing.T, cfg *config.ConfJWT) *KeySet {
+	t.Helper()
+
+	ks, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func sign(t *testing.T, ks *KeySet) string {
+	t.Helper()
+
+	token, err := ks.Sign(jwt.RegisteredClaims{Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func verify(ks *KeySet, token string) error {
+	_, err := jwt.Parse(token, ks.Keyfunc, jwt.WithValidMethods(ks.Algorithms()))
+	return err
+}
+
+func kids(ks *KeySet) []string {
+	ids := []string{}
+	for _, key := range ks.JWKS().Keys {
+		ids = append(ids, key.Kid)
+	}
+	return ids
+}
+
+func TestRotation(t *testing.T) {
+	oldPEM, oldKey := newPrivateKey(t)
+	newPEM, _ := newPrivateKey(t)
+
+	before := newKeySet(t, &config.ConfJWT{Algorithm: EdDSA, KeyID: "old", PrivateKey: oldPEM})
+	token := sign(t, before)
+
+	// The new key signs, the previous one is kept for verification during the grace window
+	grace := newKeySet(t, &config.ConfJWT{
+		Algorithm:      EdDSA,
+		KeyID:          "new",
+		PrivateKey:     newPEM,
+		VerifyKeyFiles: []string{"old=" + writePublicKey(t, "previous.pem", oldKey)},
+	})
+
+	if err := verify(grace, token); err != nil {
+		t.Errorf("token of the previous key during the grace window: %v", err)
+	}
+	if err := verify(grace, sign(t, grace)); err != nil {
+		t.Errorf("token of the active key: %v", err)
+	}
+	if got := kids(grace); !slices.Equal(got, []string{"new", "old"}) {
+		t.Errorf("JWKS during the grace window = %v, want [new old]", got)
+	}
+
+	// Once the previous key is retired its tokens are rejected
+	after := newKeySet(t, &config.ConfJWT{Algorithm: EdDSA, KeyID: "new", PrivateKey: newPEM})
+
+	if err := verify(after, token); err == nil {
+		t.Error("token of a retired key was accepted")
+	}
+	if got := kids(after); !slices.Equal(got, []string{"new"}) {
+		t.Errorf("JWKS after the rotation = %v, want [new]", got)
+	}
+}
+
+func TestVerifyKeyIDFromFileName(t *testing.T) {
+	oldPEM, oldKey := newPrivateKey(t)
+	newPEM, _ := newPrivateKey(t)
+
+	before := newKeySet(t, &config.ConfJWT{Algorithm: EdDSA, KeyID: "2024-01", PrivateKey: oldPEM})
+	ks := newKeySet(t, &config.ConfJWT{
+		Algorithm:      EdDSA,
+		KeyID:          "2024-02",
+		PrivateKey:     newPEM,
+		VerifyKeyFiles: []string{writePublicKey(t, "2024-01.pem", oldKey)},
+	})
+
+	if err := verify(ks, sign(t, before)); err != nil {
+		t.Errorf("token of the verification key: %v", err)
+	}
+}
+
+func TestDuplicateKeyID(t *testing.T) {
+	privatePEM, private := newPrivateKey(t)
+
+	_, err := New(&config.ConfJWT{
+		Algorithm:      EdDSA,
+		KeyID:          "current",
+		PrivateKey:     privatePEM,
+		VerifyKeyFiles: []string{"current=" + writePublicKey(t, "current.pem", private)},
+	})
+	if err == nil {
+		t.Fatal("a verification key reusing the id of the signing key was accepted")
+	}
+}
+
+func TestKeyfuncRejects(t *testing.T) {
+	privatePEM, _ := newPrivateKey(t)
+	ks := newKeySet(t, &config.ConfJWT{Algorithm: EdDSA, KeyID: "current", PrivateKey: privatePEM})
+	other := newKeySet(t, &config.ConfJWT{Algorithm: EdDSA, KeyID: "unknown"})
+
+	hmacToken := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "user"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown kid", sign(t, other)},
+		{"missing kid", hmacToken("")},
+		{"algorithm of another key type", hmacToken("current")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verify(ks, tt.token); err == nil {
+				t.Error("token was accepted")
+			}
+
+			// The algorithm check holds even if the caller doesn't restrict the methods
+			if _, err := jwt.Parse(tt.token, ks.Keyfunc); err == nil {
+				t.Error("token was accepted without method restriction")
+			}
+		})
+	}
+}
+
+func TestJWKSOmitsSymmetricKeys(t *testing.T) {
+	ks := newKeySet(t, &config.ConfJWT{Algorithm: HS256, HMACSecret: "secret"})
+
+	if got := kids(ks); len(got) != 0 {
+		t.Errorf("JWKS = %v, want no keys", got)
+	}
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+
+	"example.com/goapi/internal/config"
+)
+
+// Message is a plain text email
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails. Implementations are picked with the MAIL_DRIVER env variable.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the Mailer configured by cfg.Driver
+func New(cfg *config.ConfMail) Mailer {
+	switch cfg.Driver {
+	case "log":
+		return NewLogMailer(cfg.From)
+	case "file":
+		return NewFileMailer(cfg.From, cfg.FileDir)
+	case "smtp":
+		return NewSMTPMailer(cfg.From, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass)
+	default:
+		panic("Unknown mail driver: " + cfg.Driver)
+	}
+}
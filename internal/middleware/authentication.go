@@ -3,59 +3,177 @@ package middleware
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"example.com/goapi/internal/database/cache"
 	"example.com/goapi/pkg/httpx"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 )
 
 type userDataKey struct{}
 type UserDataContext struct {
-	UserID uuid.UUID
-	Roles  []string
+	UserID      uuid.UUID
+	Roles       []string
+	Permissions []string
+
+	// Details of the access token the request was authenticated with
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Set instead when the request was authenticated with an API key
+	APIKeyID uuid.UUID
+}
+
+// APIKeyVerifier resolves the user data of an API key.
+// It returns nil if the key is unknown, expired or revoked.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*UserDataContext, error)
 }
 
 var userDataContext = userDataKey{}
 
-// NOTE: The secret here is the same key we used to create accessToken.
-// Use the same key or the middleware will fail with invalid signature exception.
-// Authenticate is a middleware that checks if the request is authenticated via JWT.
-func Authenticate() func(http.Handler) http.Handler {
-	secret := "secret" // use env variable here
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tokenString := extractTokenFromHeader(r)
-			if tokenString == "" {
-				httpx.Error(w, "missing token", http.StatusUnauthorized)
-				return
-			}
+// AuthOptions configures how access tokens are verified
+type AuthOptions struct {
+	// Keyfunc resolves the key used to verify the signature of a token
+	Keyfunc jwt.Keyfunc
+	// Algorithms lists the accepted signing algorithms
+	Algorithms []string
+	// Issuer and Audience must match the iss and aud claims of a token
+	Issuer   string
+	Audience string
+	// Revocations rejects tokens revoked before their expiry, optional
+	Revocations cache.RevocationStore
+	// APIKeys enables the `Authorization: ApiKey <key>` scheme, optional
+	APIKeys APIKeyVerifier
+}
+
+// Authenticator checks if requests are authenticated via JWT.
+// NOTE: It is created once in the router and shared by every handler.
+type Authenticator struct {
+	opts AuthOptions
+}
+
+func NewAuthenticator(opts AuthOptions) *Authenticator {
+	return &Authenticator{opts: opts}
+}
+
+// Authenticate is a middleware that rejects requests without a valid access token or API key.
+func (a *Authenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := extractAPIKeyFromHeader(r); key != "" {
+			a.authenticateAPIKey(w, r, next, key)
+			return
+		}
+
+		tokenString := extractTokenFromHeader(r)
+		if tokenString == "" {
+			tokenString = extractTokenFromCookie(r)
+		}
+		if tokenString == "" {
+			httpx.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+
+		// Parse and validate the token
+		token, err := parseAndValidateToken(tokenString, a.opts)
+		if err != nil {
+			httpx.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// Extract claims from the token
+		userID, roles, err := extractUserDataFromClaims(token)
+		if err != nil {
+			httpx.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		permissions, err := extractStringsClaim(token, "permissions")
+		if err != nil {
+			httpx.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// Add the user data to the context
+		userData := UserDataContext{
+			UserID:      userID,
+			Roles:       roles,
+			Permissions: permissions,
+		}
+		extractTokenDetailsFromClaims(token, &userData)
 
-			// Parse and validate the token
-			token, err := parseAndValidateToken(tokenString, secret)
+		if a.opts.Revocations != nil {
+			revoked, err := a.opts.Revocations.IsRevoked(r.Context(), userData.TokenID, userID.String(), userData.IssuedAt)
 			if err != nil {
-				httpx.Error(w, err.Error(), http.StatusUnauthorized)
+				zerolog.Ctx(r.Context()).Error().Err(err).Msg("Failed to check token revocation")
+				httpx.Error(w, "cannot verify token", http.StatusServiceUnavailable)
 				return
 			}
 
-			// Extract claims from the token
-			userID, roles, err := extractUserDataFromClaims(token)
-			if err != nil {
-				httpx.Error(w, err.Error(), http.StatusUnauthorized)
+			if revoked {
+				httpx.Error(w, "token revoked", http.StatusUnauthorized)
 				return
 			}
+		}
 
-			// Add the user data to the context
-			userData := UserDataContext{
-				UserID: userID,
-				Roles:  roles,
-			}
+		ctx := context.WithValue(r.Context(), userDataContext, userData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuthenticate lets anonymous requests through and authenticates requests with
+// credentials like Authenticate, for public routes showing details of the caller.
+func (a *Authenticator) OptionalAuthenticate(next http.Handler) http.Handler {
+	authenticated := a.Authenticate(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if extractAPIKeyFromHeader(r) == "" && extractTokenFromHeader(r) == "" && extractTokenFromCookie(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// authenticateAPIKey serves next with the user data of the key, the scopes of a key
+// are already resolved into roles and permissions by the verifier.
+func (a *Authenticator) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	if a.opts.APIKeys == nil {
+		httpx.Error(w, "api keys are not supported", http.StatusUnauthorized)
+		return
+	}
+
+	userData, err := a.opts.APIKeys.VerifyAPIKey(r.Context(), key)
+	if err != nil {
+		zerolog.Ctx(r.Context()).Error().Err(err).Msg("Failed to verify api key")
+		httpx.Error(w, "cannot verify api key", http.StatusServiceUnavailable)
+		return
+	}
+
+	if userData == nil {
+		httpx.Error(w, "invalid api key", http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), userDataContext, *userData)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
 
-			ctx := context.WithValue(r.Context(), userDataContext, userData)
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+// Extracts the API key from the Authorization header.
+func extractAPIKeyFromHeader(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if strings.HasPrefix(header, "ApiKey ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "ApiKey "))
 	}
+
+	return ""
 }
 
 // Extracts the JWT token from the Authorization header.
@@ -68,14 +186,24 @@ func extractTokenFromHeader(r *http.Request) string {
 	return ""
 }
 
+// Extracts the JWT token from the cookie of browser clients, see CSRF.
+func extractTokenFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(AccessTokenCookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
 // Parses the JWT token and checks for expiration and validity.
-func parseAndValidateToken(tokenString, secret string) (*jwt.Token, error) {
+func parseAndValidateToken(tokenString string, opts AuthOptions) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString,
-		func(token *jwt.Token) (any, error) {
-			return []byte(secret), nil
-		},
-		jwt.WithValidMethods([]string{"HS256"}),
+		opts.Keyfunc,
+		jwt.WithValidMethods(opts.Algorithms),
 		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(opts.Issuer),
+		jwt.WithAudience(opts.Audience),
 	)
 
 	if err != nil {
@@ -124,12 +252,73 @@ func extractUserDataFromClaims(token *jwt.Token) (uuid.UUID, []string, error) {
 	return userID, roles, nil
 }
 
+// Extracts the jti, iat and exp claims. All of them are optional.
+func extractTokenDetailsFromClaims(token *jwt.Token, userData *UserDataContext) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return
+	}
+
+	userData.TokenID, _ = claims["jti"].(string)
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		userData.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		userData.ExpiresAt = exp.Time
+	}
+}
+
+// Extracts an optional list of strings from JWT claims.
+func extractStringsClaim(token *jwt.Token, name string) ([]string, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	raw, ok := claims[name]
+	if !ok || raw == nil {
+		return []string{}, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s format in token", name)
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s in token is not a string", name)
+		}
+		values = append(values, str)
+	}
+
+	return values, nil
+}
+
+// HasRole reports whether the user has the given role
+func (u UserDataContext) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// HasPermission reports whether the user has the given permission
+func (u UserDataContext) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
+}
+
 // Extract User Details from Context. Includes UserID and Roles
 func GetUserDetailsFromContext(ctx context.Context) (UserDataContext, bool) {
 	userData, ok := ctx.Value(userDataContext).(UserDataContext)
 	return userData, ok
 }
 
+// WithUserDetails returns a copy of ctx authenticated as the given user, like after Authenticate.
+// NOTE: Meant for tests and jobs running on behalf of a user, requests go through Authenticate.
+func WithUserDetails(ctx context.Context, userData UserDataContext) context.Context {
+	return context.WithValue(ctx, userDataContext, userData)
+}
+
 // Prev function where we used just authKey struct{}
 // func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 // 	userID, ok := ctx.Value(userIDKey).(uuid.UUID)
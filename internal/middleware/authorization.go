@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"example.com/goapi/pkg/httpx"
+	"github.com/google/uuid"
 )
 
 // Middleware
@@ -44,3 +45,45 @@ func AllowAccess(roles []string) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// RequirePermission allows the request only if the user has the given permission
+func RequirePermission(permission string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// This is added by the Authentication middleware
+			userData, ok := GetUserDetailsFromContext(r.Context())
+			if !ok {
+				httpx.Error(w, "user not authenticated", http.StatusUnauthorized)
+				return
+			}
+
+			if !userData.HasPermission(permission) {
+				httpx.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireInteractiveSession rejects requests authenticated with an API key. Account
+// management needs a login, whatever the scopes of a key, so a leaked key can't take
+// over the account.
+func RequireInteractiveSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// This is added by the Authentication middleware
+		userData, ok := GetUserDetailsFromContext(r.Context())
+		if !ok {
+			httpx.Error(w, "user not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		if userData.APIKeyID != uuid.Nil {
+			httpx.Error(w, "api keys can't be used for account management", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
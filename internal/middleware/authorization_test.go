@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequireInteractiveSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		userData *UserDataContext
+		want     int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"access token", &UserDataContext{UserID: uuid.New()}, http.StatusOK},
+		{"api key", &UserDataContext{UserID: uuid.New(), APIKeyID: uuid.New()}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.userData != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userDataContext, *tt.userData))
+			}
+
+			rec := httptest.NewRecorder()
+			RequireInteractiveSession(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
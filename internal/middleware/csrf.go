@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"example.com/goapi/pkg/httpx"
+)
+
+// Cookies set for browser clients
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+)
+
+// CSRFTokenHeader must echo the CSRF cookie on unsafe requests of browser clients
+const CSRFTokenHeader = "X-CSRF-Token"
+
+// CSRF protects cookie authenticated requests with the double submit pattern: unsafe methods
+// must send the value of the CSRF cookie in a header, which other sites can't read or set.
+// NOTE: Requests with an Authorization header and requests of clients without the access or
+// CSRF cookie (e.g. mobile apps using only the refresh cookie) are not affected.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "" || !hasBrowserSession(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(CSRFTokenCookie)
+		header := r.Header.Get(CSRFTokenHeader)
+		if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			httpx.Error(w, "invalid csrf token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasBrowserSession(r *http.Request) bool {
+	for _, name := range []string{AccessTokenCookie, CSRFTokenCookie} {
+		if _, err := r.Cookie(name); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
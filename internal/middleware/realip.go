@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP sets RemoteAddr to the address of the client. X-Forwarded-For and X-Real-IP are
+// only honoured when the request comes from one of the trusted proxies, anyone else could
+// spoof them, e.g. to dodge the login throttle keyed on the address.
+func RealIP(trusted []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := parseAddr(r.RemoteAddr); ok && isTrusted(trusted, peer) {
+				if client, ok := forwardedFor(r, trusted); ok {
+					r.RemoteAddr = client.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor returns the client address reported by the trusted proxies. Proxies append
+// the address they received the request from, so the right most untrusted entry of
+// X-Forwarded-For is the client, entries left of it may be made up.
+func forwardedFor(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	if header := r.Header.Values("X-Forwarded-For"); len(header) > 0 {
+		entries := strings.Split(strings.Join(header, ","), ",")
+
+		var client netip.Addr
+		for i := len(entries) - 1; i >= 0; i-- {
+			addr, ok := parseAddr(strings.TrimSpace(entries[i]))
+			if !ok {
+				break
+			}
+
+			client = addr
+			if !isTrusted(trusted, addr) {
+				break
+			}
+		}
+
+		return client, client.IsValid()
+	}
+
+	return parseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+// parseAddr parses an address with or without port
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
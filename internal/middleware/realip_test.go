@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5123", nil, "203.0.113.7:5123"},
+		{"spoofed forwarded for", "203.0.113.7:5123", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7:5123"},
+		{"spoofed real ip", "203.0.113.7:5123", map[string]string{"X-Real-IP": "198.51.100.1"}, "203.0.113.7:5123"},
+		{"trusted proxy", "10.0.0.2:443", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy chain", "10.0.0.2:443", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"made up entries left of the client", "10.0.0.2:443", map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy real ip", "10.0.0.2:443", map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy invalid header", "10.0.0.2:443", map[string]string{"X-Forwarded-For": "unknown"}, "10.0.0.2:443"},
+		{"trusted proxy without header", "10.0.0.2:443", nil, "10.0.0.2:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			RealIP(trusted)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -36,22 +36,43 @@ func (r *AuthRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 	return &user, nil
 }
 
-// Token methods
-func (r *AuthRepository) CreateRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
-	token := &auth.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    userID,
-		TokenHash: tokenHash,
-		ExpiresAt: expiresAt,
+func (r *AuthRepository) GetByID(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	var user user.User
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &user, nil
+}
+
+func (r *AuthRepository) MarkVerified(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ?", userID).
+		Update("is_verified", true).
+		Error
+}
+
+func (r *AuthRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, hash []byte) error {
+	return r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ?", userID).
+		Update("password", hash).
+		Error
+}
 
+// Token methods
+func (r *AuthRepository) CreateRefreshToken(ctx context.Context, token *auth.RefreshToken) error {
 	return r.db.WithContext(ctx).Create(token).Error
 }
 
-func (r *AuthRepository) GetRefreshTokenHash(ctx context.Context, userID uuid.UUID) (*auth.RefreshToken, error) {
+func (r *AuthRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
 	var token auth.RefreshToken
 	err := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("token_hash = ?", tokenHash).
 		First(&token).
 		Error
 
@@ -64,12 +85,26 @@ func (r *AuthRepository) GetRefreshTokenHash(ctx context.Context, userID uuid.UU
 	return &token, nil
 }
 
-func (r *AuthRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
-	return r.db.WithContext(ctx).
-		Model(&auth.RefreshToken{}).
-		Where("token_hash = ?", tokenHash).
-		Update("revoked", true).
-		Error
+func (r *AuthRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next *auth.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Conditional, so only one of concurrent rotations of the same token succeeds. The
+		// others wait for the row lock, so next is committed once they see the token revoked.
+		result := tx.Model(&auth.RefreshToken{}).
+			Where("token_hash = ? AND revoked = ?", tokenHash, false).
+			Update("revoked", true)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		rotated = true
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return rotated, nil
 }
 
 func (r *AuthRepository) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error {
@@ -79,3 +114,259 @@ func (r *AuthRepository) RevokeAllRefreshTokens(ctx context.Context, userID uuid
 		Update("revoked", true).
 		Error
 }
+
+// Session methods
+func (r *AuthRepository) ListActiveSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]*auth.RefreshToken, error) {
+	var tokens []*auth.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, now).
+		Order("last_used_at desc").
+		Find(&tokens).
+		Error
+
+	return tokens, err
+}
+
+func (r *AuthRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&auth.RefreshToken{}).
+		Where("user_id = ? AND session_id = ? AND revoked = ?", userID, sessionID, false).
+		Update("revoked", true)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Verification token methods
+func (r *AuthRepository) CreateVerificationToken(ctx context.Context, token *auth.VerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *AuthRepository) GetVerificationTokenByHash(ctx context.Context, tokenHash string) (*auth.VerificationToken, error) {
+	var token auth.VerificationToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumeVerificationToken marks the token as used. It reports false when the token was already used,
+// so concurrent requests can never consume the same token twice.
+func (r *AuthRepository) ConsumeVerificationToken(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&auth.VerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) InvalidateVerificationTokens(ctx context.Context, userID uuid.UUID, purpose string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&auth.VerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", usedAt).
+		Error
+}
+
+// Two-factor authentication methods
+func (r *AuthRepository) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	return r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ? AND totp_enabled = ?", userID, false).
+		Update("totp_secret", secret).
+		Error
+}
+
+func (r *AuthRepository) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, codes []*auth.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{"totp_enabled": true, "totp_last_step": step}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Codes of an earlier enrollment must not work anymore
+		if err := tx.Where("user_id = ?", userID).Delete(&auth.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(codes).Error
+	})
+}
+
+func (r *AuthRepository) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{"totp_enabled": false, "totp_secret": nil, "totp_last_step": 0}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&auth.RecoveryCode{}).Error
+	})
+}
+
+func (r *AuthRepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&auth.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&auth.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).
+		Error
+
+	return count, err
+}
+
+// External identity methods
+func (r *AuthRepository) CreateWithIdentity(ctx context.Context, user *user.User, identity *auth.Identity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *AuthRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("username = ?", username).
+		Count(&count).
+		Error
+
+	return count > 0, err
+}
+
+func (r *AuthRepository) CreateIdentity(ctx context.Context, identity *auth.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *AuthRepository) GetIdentity(ctx context.Context, provider, subject string) (*auth.Identity, error) {
+	var identity auth.Identity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *AuthRepository) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*auth.Identity, error) {
+	var identities []*auth.Identity
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).
+		Error
+
+	return identities, err
+}
+
+func (r *AuthRepository) TouchIdentity(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&auth.Identity{}).
+		Where("id = ?", id).
+		Update("last_login_at", at).
+		Error
+}
+
+func (r *AuthRepository) DeleteIdentity(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&auth.Identity{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// API key methods
+func (r *AuthRepository) CreateAPIKey(ctx context.Context, key *auth.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *AuthRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*auth.APIKey, error) {
+	var key auth.APIKey
+	err := r.db.WithContext(ctx).
+		Where("prefix = ?", prefix).
+		First(&key).
+		Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *AuthRepository) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*auth.APIKey, error) {
+	var keys []*auth.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).
+		Error
+
+	return keys, err
+}
+
+func (r *AuthRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, at, olderThan time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&auth.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, olderThan).
+		Update("last_used_at", at).
+		Error
+}
+
+func (r *AuthRepository) DeleteAPIKey(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&auth.APIKey{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) DeleteAllAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&auth.APIKey{}).
+		Error
+}
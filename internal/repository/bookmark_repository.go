@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/bookmark"
+	"example.com/goapi/internal/domain/post"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) bookmark.Repository {
+	return &BookmarkRepository{db: db}
+}
+
+func (r *BookmarkRepository) Set(ctx context.Context, b *bookmark.Bookmark) error {
+	// Without a collection an existing bookmark stays where it is
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoNothing: true,
+	}
+	if b.CollectionID != nil {
+		onConflict.DoNothing = false
+		onConflict.DoUpdates = clause.AssignmentColumns([]string{"collection_id"})
+	}
+
+	return r.db.WithContext(ctx).Clauses(onConflict).Create(b).Error
+}
+
+func (r *BookmarkRepository) Delete(ctx context.Context, userID, postID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&bookmark.Bookmark{}).
+		Error
+}
+
+func (r *BookmarkRepository) List(ctx context.Context, userID uuid.UUID, collectionID *uuid.UUID, fq *query.QueryParams) (post.Posts, error) {
+	bookmarked := r.db.Model(&bookmark.Bookmark{}).Select("post_id").Where("user_id = ?", userID)
+	if collectionID != nil {
+		bookmarked = bookmarked.Where("collection_id = ?", *collectionID)
+	}
+
+	// Soft deleted posts are left out by the default scope of posts, unpublished ones
+	// stay visible to their owner only
+	posts := post.Posts{}
+	db := withCommentCount(r.db.WithContext(ctx).Preload("User")).
+		Where("id IN (?)", bookmarked).
+		Where("status = ? OR user_id = ?", post.StatusPublished, userID)
+	db = query.ApplyFilters(db, fq)
+	db = query.ApplyPagination(db, fq)
+	err := sortPosts(db, fq.Sort, "created_at").Find(&posts).Error
+
+	return posts, err
+}
+
+func (r *BookmarkRepository) LoadReactions(ctx context.Context, posts post.Posts, viewerID uuid.UUID) error {
+	return loadReactions(r.db.WithContext(ctx), posts, viewerID)
+}
+
+func (r *BookmarkRepository) LoadAttachments(ctx context.Context, posts post.Posts) error {
+	return loadAttachments(r.db.WithContext(ctx), posts)
+}
+
+func (r *BookmarkRepository) CreateCollection(ctx context.Context, c *bookmark.Collection) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *BookmarkRepository) GetCollection(ctx context.Context, userID, id uuid.UUID) (*bookmark.Collection, error) {
+	var found bookmark.Collection
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&found).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *BookmarkRepository) CollectionNameExists(ctx context.Context, userID uuid.UUID, name string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&bookmark.Collection{}).
+		Where("user_id = ? AND name = ?", userID, name).
+		Count(&count).
+		Error
+
+	return count > 0, err
+}
+
+func (r *BookmarkRepository) ListCollections(ctx context.Context, userID uuid.UUID) (bookmark.Collections, error) {
+	var collections bookmark.Collections
+	err := r.db.WithContext(ctx).
+		Select("bookmark_collections.*, (SELECT COUNT(*) FROM bookmarks JOIN posts ON posts.id = bookmarks.post_id WHERE bookmarks.collection_id = bookmark_collections.id AND posts.deleted_at IS NULL) AS bookmark_count").
+		Where("user_id = ?", userID).
+		Order("name").
+		Find(&collections).
+		Error
+
+	return collections, err
+}
+
+func (r *BookmarkRepository) DeleteCollection(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&bookmark.Collection{})
+
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/goapi/internal/domain/comment"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) comment.Repository {
+	return &CommentRepository{db: db}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, c *comment.Comment) error {
+	if err := r.db.WithContext(ctx).Create(c).Error; err != nil {
+		return err
+	}
+
+	// Load the author for the response
+	return r.db.WithContext(ctx).Preload("User").First(c, "id = ?", c.ID).Error
+}
+
+func (r *CommentRepository) FindById(ctx context.Context, id uuid.UUID) (*comment.Comment, error) {
+	var found comment.Comment
+	err := r.db.WithContext(ctx).Preload("User").Where("id = ?", id).First(&found).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *CommentRepository) ListByPost(ctx context.Context, postID uuid.UUID) (comment.Comments, error) {
+	var comments comment.Comments
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("User").
+		Where("post_id = ?", postID).
+		Order("created_at ASC").
+		Find(&comments).
+		Error
+
+	return comments, err
+}
+
+func (r *CommentRepository) Update(ctx context.Context, c *comment.Comment) error {
+	return r.db.WithContext(ctx).
+		Model(c).
+		Select("content", "updated_at").
+		Updates(c).
+		Error
+}
+
+func (r *CommentRepository) DeleteById(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&comment.Comment{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -19,14 +19,112 @@ func NewFeedRepository(db *gorm.DB) feed.Repository {
 	return &FeedRepository{db: db}
 }
 
+// feedCondition matches the posts of a user and of the users they follow
+const feedCondition = "user_id = ? OR user_id IN (SELECT followee_id FROM followers WHERE follower_id = ?)"
+
+// feedOrder is the time a post entered the feed, scheduled posts enter it when they are published.
+// Timelines are built from it as well, so both ways of reading the feed share one order.
+const feedOrder = "COALESCE(publish_at, created_at)"
+
+// published hides drafts, scheduled and archived posts, the feed only shows published ones
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", post.StatusPublished)
+}
+
 func (r *FeedRepository) List(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (post.Posts, error) {
 	feed := post.Posts{}
 
-	db := r.db.WithContext(ctx).Preload("User").Where("user_id = ?", userID)
-	db = query.Apply(db, fq)
+	db := withCommentCount(r.db.WithContext(ctx).Preload("User")).
+		Where(feedCondition, userID, userID).
+		Scopes(published)
+	db = query.ApplyFilters(db, fq)
+	db = query.ApplyPagination(db, fq)
+	db = sortPosts(db, fq.Sort, feedOrder)
 	if err := db.Find(&feed).Error; err != nil {
 		return nil, errors.New(errors.ErrDBAccessFailure, "Resource Not Found", err)
 	}
 
 	return feed, nil
 }
+
+func (r *FeedRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) (post.Posts, error) {
+	posts := post.Posts{}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	err := withCommentCount(r.db.WithContext(ctx).Preload("User")).
+		Where("id IN ?", ids).
+		Scopes(published).
+		Find(&posts).
+		Error
+
+	return posts, err
+}
+
+func (r *FeedRepository) LoadReactions(ctx context.Context, posts post.Posts, viewerID uuid.UUID) error {
+	return loadReactions(r.db.WithContext(ctx), posts, viewerID)
+}
+
+func (r *FeedRepository) LoadAttachments(ctx context.Context, posts post.Posts) error {
+	return loadAttachments(r.db.WithContext(ctx), posts)
+}
+
+func (r *FeedRepository) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("followers").Where("followee_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *FeedRepository) FollowerIDs(ctx context.Context, userID, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Table("followers").
+		Where("followee_id = ? AND follower_id > ?", userID, after).
+		Order("follower_id").
+		Limit(limit).
+		Pluck("follower_id", &ids).
+		Error
+
+	return ids, err
+}
+
+func (r *FeedRepository) FollowedAuthors(ctx context.Context, userID uuid.UUID, minFollowers int64) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Table("followers AS f").
+		Where("f.follower_id = ?", userID).
+		Where("(SELECT COUNT(*) FROM followers WHERE followers.followee_id = f.followee_id) >= ?", minFollowers).
+		Pluck("f.followee_id", &ids).
+		Error
+
+	return ids, err
+}
+
+func (r *FeedRepository) ListEntries(ctx context.Context, userID uuid.UUID, exclude []uuid.UUID, limit int) ([]feed.Entry, error) {
+	db := r.db.WithContext(ctx).Model(&post.Post{}).Where(feedCondition, userID, userID).Scopes(published)
+	if len(exclude) > 0 {
+		db = db.Where("user_id NOT IN ?", exclude)
+	}
+
+	return r.listEntries(db, limit)
+}
+
+func (r *FeedRepository) ListEntriesByAuthors(ctx context.Context, authors []uuid.UUID, limit int) ([]feed.Entry, error) {
+	if len(authors) == 0 {
+		return []feed.Entry{}, nil
+	}
+
+	return r.listEntries(r.db.WithContext(ctx).Model(&post.Post{}).Where("user_id IN ?", authors).Scopes(published), limit)
+}
+
+func (r *FeedRepository) listEntries(db *gorm.DB, limit int) ([]feed.Entry, error) {
+	var entries []feed.Entry
+	err := db.Select("id AS post_id", feedOrder+" AS created_at").
+		Order(feedOrder + " DESC").
+		Limit(limit).
+		Scan(&entries).
+		Error
+
+	return entries, err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"example.com/goapi/internal/domain/follow"
+	"example.com/goapi/internal/domain/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) follow.Repository {
+	return &FollowRepository{db: db}
+}
+
+func (r *FollowRepository) Create(ctx context.Context, f *follow.Follow) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", f.FolloweeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(f).
+		Error
+
+	return true, err
+}
+
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&follow.Follow{}).
+		Error
+}
+
+func (r *FollowRepository) ListFollowers(ctx context.Context, userID uuid.UUID, limit, offset int) (user.Users, int64, error) {
+	return r.listUsers(ctx, "followers.follower_id", "followers.followee_id", userID, limit, offset)
+}
+
+func (r *FollowRepository) ListFollowing(ctx context.Context, userID uuid.UUID, limit, offset int) (user.Users, int64, error) {
+	return r.listUsers(ctx, "followers.followee_id", "followers.follower_id", userID, limit, offset)
+}
+
+// listUsers joins the users on one side of the relation where the other side is userID, newest first
+func (r *FollowRepository) listUsers(ctx context.Context, joinColumn, whereColumn string, userID uuid.UUID, limit, offset int) (user.Users, int64, error) {
+	db := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Joins("JOIN followers ON "+joinColumn+" = users.id").
+		Where(whereColumn+" = ?", userID).
+		Session(&gorm.Session{}) // Shared by the count and the page query
+
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users user.Users
+	err := db.Order("followers.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).
+		Error
+
+	return users, count, err
+}
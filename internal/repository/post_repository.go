@@ -2,12 +2,19 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
 	"example.com/goapi/internal/common/errors"
+	"example.com/goapi/internal/common/query"
 	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/revision"
+	"example.com/goapi/internal/domain/upload"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository struct {
@@ -19,51 +26,122 @@ func NewRepository(db *gorm.DB) post.Repository {
 }
 
 func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
-	return r.db.WithContext(ctx).Create(p).Error
+	if len(p.AttachmentIDs) == 0 {
+		return r.db.WithContext(ctx).Create(p).Error
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+
+		return replaceAttachments(tx, p.ID, p.AttachmentIDs)
+	})
 }
 
 func (r *PostRepository) FindAll(ctx context.Context, query *post.SearchQuery) (post.Posts, error) {
 	var posts post.Posts
-	db := r.buildPostQuery(query).WithContext(ctx)
+	db := withCommentCount(r.buildPostQuery(query).WithContext(ctx))
 	err := db.Preload("User").Find(&posts).Error
 	return posts, err
 }
 
 func (r *PostRepository) FindById(ctx context.Context, id uuid.UUID) (*post.Post, error) {
 	post := &post.Post{}
-	if err := r.db.Preload("User").WithContext(ctx).Where("id=?", id).First(post).Error; err != nil {
-		return nil, err
+	if err := withCommentCount(r.db.Preload("User").WithContext(ctx)).Where("id=?", id).First(post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), err)
+		}
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataAccessFailure, err)
 	}
 
 	return post, nil
 }
 
-func (r *PostRepository) Update(ctx context.Context, input *post.Post) (*post.Post, error) {
-	// Fetch the current post by ID to get all current data including version
-	var toUpdate post.Post
-	if err := r.db.WithContext(ctx).First(&toUpdate, "id = ?", input.ID).Error; err != nil {
-		return nil, err
+// editableColumns are written by Update unless only some of them are given
+var editableColumns = []string{"title", "content", "tags", "status", "publish_at"}
+
+func (r *PostRepository) Update(ctx context.Context, input *post.Post, columns ...string) (*post.Post, error) {
+	if len(columns) == 0 {
+		columns = editableColumns
+		// Attachments are kept unless new ones are given
+		if input.AttachmentIDs != nil {
+			columns = append(slices.Clone(columns), "attachments")
+		}
 	}
 
-	// Store the current version for optimistic locking
-	currentVersion := toUpdate.Version
+	var toUpdate post.Post
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Fetch the current post by ID to get all current data including version.
+		// The row stays locked so concurrent updates see the version written here.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&toUpdate, "id = ?", input.ID).Error
+		if err != nil {
+			return err
+		}
+
+		// The update must be based on the current version (optimistic locking)
+		currentVersion := toUpdate.Version
+		if input.Version != currentVersion {
+			return errors.New(errors.ErrDBVersionConflict, errors.DBVersionConflict, nil)
+		}
+
+		// Keep the version being replaced
+		if err := tx.Create(revision.FromPost(&toUpdate)).Error; err != nil {
+			return err
+		}
 
-	// Update the fields from input (only update fields that should be updated)
-	toUpdate.Title = input.Title
-	toUpdate.Content = input.Content
-	toUpdate.Tags = input.Tags
-	// Update any other fields that need updating...
+		// Update the fields from input (only update fields that should be updated)
+		selected := []string{"version", "updated_at"}
+		for _, column := range columns {
+			if column != "attachments" {
+				selected = append(selected, column)
+			}
 
-	// Increment the version for concurrency control
-	toUpdate.Version = currentVersion + 1
+			switch column {
+			case "title":
+				toUpdate.Title = input.Title
+			case "content":
+				toUpdate.Content = input.Content
+			case "tags":
+				toUpdate.Tags = input.Tags
+			case "status":
+				toUpdate.Status = input.Status
+			case "publish_at":
+				toUpdate.PublishAt = input.PublishAt
+			case "attachments":
+				// Not a column of posts, written along with them
+				if err := replaceAttachments(tx, toUpdate.ID, input.AttachmentIDs); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("column '%s' cannot be updated", column)
+			}
+		}
 
-	// Perform the update with version check
-	result := r.db.WithContext(ctx).Model(&post.Post{}).
-		Where("id = ? AND version = ?", toUpdate.ID, currentVersion).
-		Updates(&toUpdate)
+		// Increment the version for concurrency control
+		toUpdate.Version = currentVersion + 1
 
-	if result.Error != nil || result.RowsAffected == 0 {
-		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataUpdateFailure, result.Error)
+		// Perform the update with version check
+		// NOTE: Selected so that only these columns are written, including cleared ones
+		result := tx.Model(&post.Post{}).
+			Select(selected).
+			Where("id = ? AND version = ?", toUpdate.ID, currentVersion).
+			Updates(&toUpdate)
+
+		if result.Error == nil && result.RowsAffected == 0 {
+			return errors.New(errors.ErrDBVersionConflict, errors.DBVersionConflict, nil)
+		}
+
+		return result.Error
+	})
+	if err != nil {
+		if apiErr, ok := err.(*errors.ApiError); ok {
+			return nil, apiErr
+		}
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, input.ID), err)
+		}
+		return nil, errors.New(errors.ErrDBAccessFailure, errors.DBDataUpdateFailure, err)
 	}
 
 	// Return the updated post
@@ -71,23 +149,149 @@ func (r *PostRepository) Update(ctx context.Context, input *post.Post) (*post.Po
 }
 
 func (r *PostRepository) DeleteById(ctx context.Context, id uuid.UUID) error {
-	result := r.db.Where("id = ?", id).Delete(&post.Post{})
-	if result.RowsAffected > 0 {
-		return nil
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&post.Post{})
+	if result.Error != nil {
+		return errors.New(errors.ErrDBDeleteFailure, errors.DBDataRemoveFailure, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrDBNoRows, fmt.Sprintf(errors.ResourceNotFound, id), nil)
 	}
 
-	return result.Error
+	return nil
+}
+
+func (r *PostRepository) ListTrash(ctx context.Context, userID uuid.UUID, fq *query.QueryParams) (post.Posts, error) {
+	posts := post.Posts{}
+	db := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("User").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC")
+	err := query.ApplyPagination(db, fq).Find(&posts).Error
+
+	return posts, err
+}
+
+func (r *PostRepository) FindTrashed(ctx context.Context, id uuid.UUID) (*post.Post, error) {
+	var found post.Post
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&found).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *PostRepository) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&post.Post{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PostRepository) HardDelete(ctx context.Context, id uuid.UUID) (bool, error) {
+	// Comments, reactions, bookmarks and revisions are removed by their foreign keys
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&post.Post{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PostRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	expired := r.db.Unscoped().
+		Model(&post.Post{}).
+		Select("id").
+		Where("deleted_at < ?", before).
+		Limit(limit)
+
+	result := r.db.WithContext(ctx).Unscoped().Where("id IN (?)", expired).Delete(&post.Post{})
+	return result.RowsAffected, result.Error
 }
 
 func (r *PostRepository) SearchByText(ctx context.Context, query string) (post.Posts, error) {
 	var posts post.Posts
-	if err := r.db.WithContext(ctx).Where("tsv @@ to_tsquery(?)", query).Preload("User").Find(&posts).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("tsv @@ to_tsquery(?) AND status = ?", query, post.StatusPublished).Preload("User").Find(&posts).Error; err != nil {
 		return nil, errors.New(errors.ErrDBNoRows, "no resource found", err)
 	}
 
 	return posts, nil
 }
 
+func (r *PostRepository) PublishDue(ctx context.Context, now time.Time, limit int) (post.Posts, error) {
+	due := post.Posts{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Rows locked by another instance are skipped, that instance publishes them
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ?", post.StatusScheduled, now).
+			Order("publish_at").
+			Limit(limit).
+			Find(&due).
+			Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		// Publishing is a new version like any other update, keep the ones being replaced
+		ids := make([]uuid.UUID, len(due))
+		revisions := make(revision.Revisions, len(due))
+		for i, p := range due {
+			ids[i] = p.ID
+			revisions[i] = revision.FromPost(p)
+		}
+
+		if err := tx.Create(&revisions).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&post.Post{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":     post.StatusPublished,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": now,
+			}).
+			Error
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	for _, p := range due {
+		p.Status = post.StatusPublished
+		p.Version++
+		p.UpdatedAt = now
+	}
+
+	return due, nil
+}
+
+func (r *PostRepository) LoadReactions(ctx context.Context, posts post.Posts, viewerID uuid.UUID) error {
+	return loadReactions(r.db.WithContext(ctx), posts, viewerID)
+}
+
+func (r *PostRepository) LoadAttachments(ctx context.Context, posts post.Posts) error {
+	return loadAttachments(r.db.WithContext(ctx), posts)
+}
+
+func (r *PostRepository) CountUploads(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&upload.Upload{}).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Count(&count).
+		Error
+	return count, err
+}
+
+// withCommentCount selects the number of visible comments along with the posts
+func withCommentCount(db *gorm.DB) *gorm.DB {
+	return db.Select("posts.*, (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) AS comment_count")
+}
+
 func (r *PostRepository) buildPostQuery(query *post.SearchQuery) *gorm.DB {
 	db := r.db
 
@@ -107,5 +311,15 @@ func (r *PostRepository) buildPostQuery(query *post.SearchQuery) *gorm.DB {
 		db = db.Where("user_id = ?", query.UserID)
 	}
 
+	if query.ViewerID != uuid.Nil {
+		db = db.Where("status = ? OR user_id = ?", post.StatusPublished, query.ViewerID)
+	} else {
+		db = db.Where("status = ?", post.StatusPublished)
+	}
+
+	if query.Sort != "" {
+		db = sortPosts(db, query.Sort, "created_at")
+	}
+
 	return db
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+
+	"example.com/goapi/internal/common/query"
+	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/reaction"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) reaction.Repository {
+	return &ReactionRepository{db: db}
+}
+
+func (r *ReactionRepository) Set(ctx context.Context, rc *reaction.Reaction) error {
+	// Leaves an identical reaction untouched, so the counts don't change
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"kind"}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "post_reactions.kind <> excluded.kind"}}},
+		}).
+		Create(rc).
+		Error
+}
+
+func (r *ReactionRepository) Delete(ctx context.Context, postID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("post_id = ? AND user_id = ?", postID, userID).
+		Delete(&reaction.Reaction{}).
+		Error
+}
+
+func (r *ReactionRepository) Counts(ctx context.Context, postID uuid.UUID) (map[string]int64, error) {
+	counts, err := reactionCounts(r.db.WithContext(ctx), []uuid.UUID{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := counts[postID]; ok {
+		return c, nil
+	}
+
+	return map[string]int64{}, nil
+}
+
+// loadReactions sets the counts per kind and the reaction of the viewer on the posts.
+// Anonymous viewers are passed as uuid.Nil.
+func loadReactions(db *gorm.DB, posts post.Posts, viewerID uuid.UUID) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	counts, err := reactionCounts(db, ids)
+	if err != nil {
+		return err
+	}
+
+	var own []reaction.Reaction
+	if viewerID != uuid.Nil {
+		err := db.Where("user_id = ? AND post_id IN ?", viewerID, ids).Find(&own).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	kinds := make(map[uuid.UUID]string, len(own))
+	for _, rc := range own {
+		kinds[rc.PostID] = rc.Kind
+	}
+
+	for _, p := range posts {
+		p.Reactions = counts[p.ID]
+		if kind, ok := kinds[p.ID]; ok {
+			p.ViewerReaction = &kind
+		}
+	}
+
+	return nil
+}
+
+func reactionCounts(db *gorm.DB, postIDs []uuid.UUID) (map[uuid.UUID]map[string]int64, error) {
+	var rows []struct {
+		PostID uuid.UUID
+		Kind   string
+		Count  int64
+	}
+
+	err := db.Table("post_reaction_counts").
+		Where("post_id IN ? AND count > 0", postIDs).
+		Find(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]map[string]int64)
+	for _, row := range rows {
+		if counts[row.PostID] == nil {
+			counts[row.PostID] = map[string]int64{}
+		}
+		counts[row.PostID][row.Kind] = row.Count
+	}
+
+	return counts, nil
+}
+
+// mostReacted orders posts by their number of reactions of any kind
+const mostReacted = "(SELECT COALESCE(SUM(count), 0) FROM post_reaction_counts WHERE post_reaction_counts.post_id = posts.id) desc"
+
+// sortPosts orders a post list by column like query.ApplySortingBy, or by post.SortMostReacted
+// with the newest first among posts with as many reactions
+func sortPosts(db *gorm.DB, sort, column string) *gorm.DB {
+	if sort == post.SortMostReacted {
+		return db.Order(mostReacted).Order(column + " desc")
+	}
+
+	return query.ApplySortingBy(db, &query.QueryParams{Sort: sort}, column)
+}
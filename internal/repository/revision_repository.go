@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/goapi/internal/domain/revision"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewRevisionRepository(db *gorm.DB) revision.Repository {
+	return &RevisionRepository{db: db}
+}
+
+func (r *RevisionRepository) List(ctx context.Context, postID uuid.UUID) (revision.Revisions, error) {
+	revisions := revision.Revisions{}
+	err := r.db.WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions).
+		Error
+
+	return revisions, err
+}
+
+func (r *RevisionRepository) Find(ctx context.Context, postID uuid.UUID, version uint) (*revision.Revision, error) {
+	var found revision.Revision
+	err := r.db.WithContext(ctx).Where("post_id = ? AND version = ?", postID, version).First(&found).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"example.com/goapi/internal/domain/role"
+	"example.com/goapi/internal/domain/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) role.Repository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) List(ctx context.Context) (role.Roles, error) {
+	var roles role.Roles
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*role.Role, error) {
+	var found role.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&found).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *RoleRepository) ListUserRoles(ctx context.Context, userID uuid.UUID) (role.Roles, error) {
+	var roles role.Roles
+	err := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).
+		Error
+
+	return roles, err
+}
+
+func (r *RoleRepository) Grant(ctx context.Context, grant *role.UserRole) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", grant.UserID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, nil
+	}
+
+	// Granting a role twice is a no-op
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(grant).
+		Error
+
+	return true, err
+}
+
+func (r *RoleRepository) GrantByEmail(ctx context.Context, email string, roleID uuid.UUID) (bool, error) {
+	var users []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("email = ? AND is_verified = ?", email, true).
+		Pluck("id", &users).
+		Error
+	if err != nil || len(users) == 0 {
+		return false, err
+	}
+
+	// Granting a role twice is a no-op
+	err = r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&role.UserRole{UserID: users[0], RoleID: roleID}).
+		Error
+
+	return err == nil, err
+}
+
+func (r *RoleRepository) Revoke(ctx context.Context, userID, roleID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND role_id = ?", userID, roleID).
+		Delete(&role.UserRole{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RoleRepository) RevokeUnlessLast(ctx context.Context, userID, roleID uuid.UUID) (bool, bool, error) {
+	var revoked, last bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locks the holders, so concurrent revokes can't remove the last two at once
+		var holders []uuid.UUID
+		err := tx.Model(&role.UserRole{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role_id = ?", roleID).
+			Pluck("user_id", &holders).
+			Error
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(holders, userID) {
+			return nil
+		}
+
+		if len(holders) == 1 {
+			last = true
+			return nil
+		}
+
+		result := tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&role.UserRole{})
+		revoked = result.RowsAffected > 0
+		return result.Error
+	})
+
+	return revoked, last, err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/upload"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) upload.Repository {
+	return &UploadRepository{db: db}
+}
+
+func (r *UploadRepository) Create(ctx context.Context, u *upload.Upload) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "checksum"}},
+			DoNothing: true,
+		}).
+		Create(u)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UploadRepository) FindByChecksum(ctx context.Context, userID uuid.UUID, checksum string) (*upload.Upload, error) {
+	return r.first(r.db.WithContext(ctx).Where("user_id = ? AND checksum = ?", userID, checksum))
+}
+
+func (r *UploadRepository) FindStored(ctx context.Context, checksum string) (*upload.Upload, error) {
+	return r.first(r.db.WithContext(ctx).Where("checksum = ?", checksum).Order("created_at"))
+}
+
+func (r *UploadRepository) first(db *gorm.DB) (*upload.Upload, error) {
+	var found upload.Upload
+	if err := db.First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+// replaceAttachments attaches the uploads to the post in the given order, replacing the current ones
+func replaceAttachments(tx *gorm.DB, postID uuid.UUID, uploadIDs []uuid.UUID) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&upload.Attachment{}).Error; err != nil {
+		return err
+	}
+
+	if len(uploadIDs) == 0 {
+		return nil
+	}
+
+	attachments := make([]upload.Attachment, len(uploadIDs))
+	for i, id := range uploadIDs {
+		attachments[i] = upload.Attachment{PostID: postID, UploadID: id, Position: i}
+	}
+
+	return tx.Create(&attachments).Error
+}
+
+// loadAttachments sets the uploads attached to the posts, in display order
+func loadAttachments(db *gorm.DB, posts post.Posts) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	var attachments []upload.Attachment
+	if err := db.Where("post_id IN ?", ids).Order("position").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	uploadIDs := make([]uuid.UUID, len(attachments))
+	for i, a := range attachments {
+		uploadIDs[i] = a.UploadID
+	}
+
+	uploads := upload.Uploads{}
+	if len(uploadIDs) > 0 {
+		if err := db.Where("id IN ?", uploadIDs).Find(&uploads).Error; err != nil {
+			return err
+		}
+	}
+
+	byID := make(map[uuid.UUID]*upload.Upload, len(uploads))
+	for _, u := range uploads {
+		byID[u.ID] = u
+	}
+
+	byPost := make(map[uuid.UUID]upload.Uploads, len(posts))
+	for _, a := range attachments {
+		if u, ok := byID[a.UploadID]; ok {
+			byPost[a.PostID] = append(byPost[a.PostID], u)
+		}
+	}
+
+	for _, p := range posts {
+		p.Attachments = byPost[p.ID]
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"example.com/goapi/internal/common/errors"
 	"example.com/goapi/internal/domain/user"
@@ -51,6 +52,40 @@ func (r *UserRepository) Update(ctx context.Context, user *user.User) (*user.Use
 	return user, nil
 }
 
+func (r *UserRepository) Patch(ctx context.Context, u *user.User, columns ...string) (*user.User, error) {
+	// NOTE: Unlike Save, only the given columns are written, e.g. the TOTP columns stay untouched
+	err := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ?", u.ID).
+		Select(append(slices.Clone(columns), "updated_at")).
+		Updates(u).
+		Error
+	if err != nil {
+		return nil, errors.New(errors.ErrDBUpdateFailure, errors.DBDataUpdateFailure, err)
+	}
+
+	return r.GetByID(ctx, u.ID)
+}
+
+func (r *UserRepository) UsernameTaken(ctx context.Context, username string, exceptID uuid.UUID) (bool, error) {
+	return r.taken(ctx, "username", username, exceptID)
+}
+
+func (r *UserRepository) EmailTaken(ctx context.Context, email string, exceptID uuid.UUID) (bool, error) {
+	return r.taken(ctx, "email", email, exceptID)
+}
+
+func (r *UserRepository) taken(ctx context.Context, column, value string, exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where(column+" = ? AND id <> ?", value, exceptID).
+		Count(&count).
+		Error
+
+	return count > 0, err
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&user.User{}).Error; err != nil {
 		return err
@@ -5,14 +5,26 @@ import (
 	"net/http"
 	"time"
 
+	"example.com/goapi/internal/config"
 	"example.com/goapi/internal/database/cache"
 	"example.com/goapi/internal/domain/auth"
+	"example.com/goapi/internal/domain/bookmark"
+	"example.com/goapi/internal/domain/comment"
 	"example.com/goapi/internal/domain/feed"
+	"example.com/goapi/internal/domain/follow"
 	"example.com/goapi/internal/domain/post"
+	"example.com/goapi/internal/domain/reaction"
+	"example.com/goapi/internal/domain/revision"
+	"example.com/goapi/internal/domain/role"
+	"example.com/goapi/internal/domain/upload"
 	"example.com/goapi/internal/domain/user"
 	v1 "example.com/goapi/internal/handler/v1"
+	"example.com/goapi/internal/jwtkeys"
+	"example.com/goapi/internal/mailer"
 	m "example.com/goapi/internal/middleware"
 	"example.com/goapi/internal/repository"
+	"example.com/goapi/internal/storage"
+	"example.com/goapi/pkg/oidc"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -23,63 +35,169 @@ import (
 	_ "example.com/goapi/docs"
 )
 
-func NewRouter(db *gorm.DB, v *validator.Validate, rd *cache.Client) http.Handler {
+// NOTE: fanOut pushes new posts into the timelines of followers, it is shared with the
+// background jobs started by the server.
+func NewRouter(c *config.Conf, db *gorm.DB, v *validator.Validate, rd *cache.Client, ks *jwtkeys.KeySet, fanOut post.Publisher) http.Handler {
 	r := chi.NewRouter()
-	applyMiddlewares(r)
+	applyMiddlewares(r, c)
+
+	authn := m.NewAuthenticator(m.AuthOptions{
+		Keyfunc:     ks.Keyfunc,
+		Algorithms:  ks.Algorithms(),
+		Issuer:      c.Auth.Issuer,
+		Audience:    c.Auth.Audience,
+		Revocations: rd,
+		APIKeys:     auth.NewAPIKeyVerifier(repository.NewAuthRepository(db), repository.NewRoleRepository(db)),
+	})
 
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 	r.Get("/debug/vars", expvar.Handler().ServeHTTP)
+	r.Get("/.well-known/jwks.json", ks.JWKSHandler())
+
+	// Blob store of uploads, files of the local store are served by the API itself
+	store := storage.New(c.Media)
+	if local, ok := store.(*storage.LocalStore); ok {
+		r.Handle("/media/*", http.StripPrefix("/media", local.Handler()))
+	}
+
+	// Users changing their email are sent the verification email of auth
+	authService := newAuthService(c, db, rd, ks)
+
 	r.Route("/api/v1", func(r chi.Router) {
-		registerPostRoutes(r, db, v, rd)
-		registerUserRoutes(r, db, v, rd)
-		registerFeedRoutes(r, db, v, rd)
-		registerAuthRoutes(r, db, v, rd)
+		registerPostRoutes(r, db, v, rd, authn, fanOut)
+		registerCommentRoutes(r, db, v, authn)
+		registerReactionRoutes(r, c, db, v, rd, authn)
+		registerRevisionRoutes(r, db, authn)
+		registerBookmarkRoutes(r, db, v, authn)
+		registerUploadRoutes(r, c, db, store, authn)
+		registerUserRoutes(r, db, v, authService, authn)
+		registerFollowRoutes(r, db, v, rd, authn)
+		registerFeedRoutes(r, c, db, v, rd, authn)
+		registerAuthRoutes(r, c, v, authService, authn)
+		registerRoleRoutes(r, c, db, v, rd, authn)
 	})
 
 	return r
 }
 
-func registerPostRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client) {
+func registerPostRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client, authn *m.Authenticator, pub post.Publisher) {
 	repo := repository.NewRepository(db)
-	service := post.NewService(repo)
-	handler := v1.NewHandler(service, v, rd)
+	service := post.NewService(repo, pub)
+	handler := v1.NewHandler(service, v, rd, authn)
 	handler.RegisterRoutes(r)
 }
 
-func registerUserRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client) {
+func registerCommentRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, authn *m.Authenticator) {
+	repo := repository.NewCommentRepository(db)
+	service := comment.NewService(repo, repository.NewRepository(db))
+	handler := v1.NewCommentHandler(service, v, authn)
+	handler.RegisterCommentRoutes(r)
+}
+
+func registerReactionRoutes(r chi.Router, c *config.Conf, db *gorm.DB, v *validator.Validate, rd *cache.Client, authn *m.Authenticator) {
+	repo := repository.NewReactionRepository(db)
+	service := reaction.NewService(repo, repository.NewRepository(db), rd, c.Post)
+	handler := v1.NewReactionHandler(service, v, authn)
+	handler.RegisterReactionRoutes(r)
+}
+
+func registerRevisionRoutes(r chi.Router, db *gorm.DB, authn *m.Authenticator) {
+	repo := repository.NewRevisionRepository(db)
+	service := revision.NewService(repo, repository.NewRepository(db))
+	handler := v1.NewRevisionHandler(service, authn)
+	handler.RegisterRevisionRoutes(r)
+}
+
+func registerBookmarkRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, authn *m.Authenticator) {
+	repo := repository.NewBookmarkRepository(db)
+	service := bookmark.NewService(repo, repository.NewRepository(db))
+	handler := v1.NewBookmarkHandler(service, v, authn)
+	handler.RegisterBookmarkRoutes(r)
+}
+
+func registerUploadRoutes(r chi.Router, c *config.Conf, db *gorm.DB, store storage.Store, authn *m.Authenticator) {
+	repo := repository.NewUploadRepository(db)
+	service := upload.NewService(repo, store, c.Media)
+	handler := v1.NewUploadHandler(service, authn, c.Media.MaxSize)
+	handler.RegisterUploadRoutes(r)
+}
+
+func registerUserRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, verifier user.EmailVerifier, authn *m.Authenticator) {
 	repo := repository.NewUserRepository(db)
-	service := user.NewService(repo)
-	handler := v1.NewUserHandler(service, v)
+	service := user.NewService(repo, verifier)
+	handler := v1.NewUserHandler(service, v, authn)
 	handler.RegisterUserRoutes(r)
 }
 
-func registerFeedRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client) {
+func registerFollowRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client, authn *m.Authenticator) {
+	repo := repository.NewFollowRepository(db)
+	service := follow.NewService(repo, rd)
+	handler := v1.NewFollowHandler(service, v, authn)
+	handler.RegisterFollowRoutes(r)
+}
+
+func registerFeedRoutes(r chi.Router, c *config.Conf, db *gorm.DB, v *validator.Validate, rd *cache.Client, authn *m.Authenticator) {
 	repo := repository.NewFeedRepository(db)
-	service := feed.NewService(repo)
-	handler := v1.NewFeedHandler(service, v)
+	service := feed.NewService(repo, rd, c.Feed)
+	handler := v1.NewFeedHandler(service, v, authn)
 	handler.RegisterFeedRoutes(r)
 }
 
-func registerAuthRoutes(r chi.Router, db *gorm.DB, v *validator.Validate, rd *cache.Client) {
-	repo := repository.NewAuthRepository(db)
-	service := auth.NewService(repo, "secret", "refresh", 15*time.Minute, 7*24*time.Hour)
-	handler := v1.NewAuthHandler(service, v)
+func registerAuthRoutes(r chi.Router, c *config.Conf, v *validator.Validate, service auth.Service, authn *m.Authenticator) {
+	handler := v1.NewAuthHandler(service, v, authn, c.Auth)
 	handler.RegisterAuthRoutes(r)
 }
 
-func applyMiddlewares(r *chi.Mux) {
+func newAuthService(c *config.Conf, db *gorm.DB, rd *cache.Client, ks *jwtkeys.KeySet) auth.Service {
+	repo := repository.NewAuthRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	ml := mailer.New(c.Mail)
+	return auth.NewService(repo, roleRepo, ml, c.Mail.AppURL, ks, rd, rd, newIdentityProviders(c.OIDC), c.Auth)
+}
+
+// newIdentityProviders creates an OIDC client for every configured provider
+func newIdentityProviders(c *config.ConfOIDC) map[string]*oidc.Client {
+	idps := make(map[string]*oidc.Client, len(c.Provider))
+	for name, p := range c.Provider {
+		idps[name] = oidc.NewClient(oidc.Config{
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  c.RedirectURL(name),
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+
+	return idps
+}
+
+func registerRoleRoutes(r chi.Router, c *config.Conf, db *gorm.DB, v *validator.Validate, rd *cache.Client, authn *m.Authenticator) {
+	repo := repository.NewRoleRepository(db)
+	service := role.NewService(repo, rd, c.Auth)
+	handler := v1.NewRoleHandler(service, v, authn)
+	handler.RegisterRoleRoutes(r)
+}
+
+func applyMiddlewares(r *chi.Mux, c *config.Conf) {
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	// Proxy headers are only trusted from the configured proxies, see config.ConfServer
+	proxies, err := c.Server.TrustedProxyPrefixes()
+	if err != nil {
+		panic(err.Error())
+	}
+	r.Use(m.RealIP(proxies))
 	r.Use(middleware.Recoverer)
 	// r.Use(middleware.Logger) // Not required because we have our own middleware for logging
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(m.LogContext)
 	r.Use(cors.Handler(cors.Options{
-		// Allow all origins for simplicity
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
-		ExposedHeaders: []string{"X-Custom-Header"},
-		MaxAge:         300, // 5 minutes
+		// Credentials (cookies) are only accepted from the configured origins, never from "*"
+		AllowedOrigins:   c.Server.CORSAllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", m.CSRFTokenHeader, "X-Auth-Mode", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"X-Custom-Header", "ETag"},
+		AllowCredentials: true,
+		MaxAge:           300, // 5 minutes
 	}))
+	r.Use(m.CSRF)
 }
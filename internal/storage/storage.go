@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"example.com/goapi/internal/config"
+)
+
+// Store keeps uploaded files as blobs under a key. Implementations are picked with the
+// MEDIA_DRIVER env variable.
+type Store interface {
+	// Put stores size bytes of body under key, replacing an existing blob
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the blob is served under
+	URL(key string) string
+}
+
+// New creates the Store configured by cfg.Driver
+func New(cfg *config.ConfMedia) Store {
+	switch cfg.Driver {
+	case "local":
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = "/media"
+		}
+		return NewLocalStore(cfg.LocalDir, publicURL)
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.PublicURL,
+		})
+	default:
+		panic("Unknown media driver: " + cfg.Driver)
+	}
+}
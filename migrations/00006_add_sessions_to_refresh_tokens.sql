@@ -0,0 +1,22 @@
+-- +goose Up
+-- Track refresh tokens per device session. A session survives token rotation,
+-- every rotated token inherits the session_id of the token it replaces.
+ALTER TABLE refresh_tokens
+    ADD COLUMN session_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN device_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN signed_in_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_session_id;
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS signed_in_at,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS device_name,
+    DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Single-use tokens sent to users by email (email verification, password reset, ...)
+CREATE TABLE IF NOT EXISTS verification_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    purpose VARCHAR(32) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_tokens_user_id_purpose ON verification_tokens(user_id, purpose);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_verification_tokens_user_id_purpose;
+DROP TABLE IF EXISTS verification_tokens;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Social graph, a row means follower_id follows followee_id
+CREATE TABLE IF NOT EXISTS followers (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_followers_followee_id ON followers(followee_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_followers_followee_id;
+DROP TABLE IF EXISTS followers;
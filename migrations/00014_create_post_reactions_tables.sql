@@ -0,0 +1,48 @@
+-- +goose Up
+-- One reaction per user and post
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_user_id ON post_reactions(user_id);
+
+-- Denormalised counts per kind, kept in sync by a trigger so cascading deletes are counted too
+CREATE TABLE IF NOT EXISTS post_reaction_counts (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (post_id, kind)
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION sync_post_reaction_counts() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE post_reaction_counts SET count = count - 1
+        WHERE post_id = OLD.post_id AND kind = OLD.kind;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO post_reaction_counts (post_id, kind, count) VALUES (NEW.post_id, NEW.kind, 1)
+        ON CONFLICT (post_id, kind) DO UPDATE SET count = post_reaction_counts.count + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER post_reactions_sync_counts
+AFTER INSERT OR DELETE OR UPDATE OF kind ON post_reactions
+FOR EACH ROW EXECUTE FUNCTION sync_post_reaction_counts();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS post_reactions_sync_counts ON post_reactions;
+DROP FUNCTION IF EXISTS sync_post_reaction_counts();
+DROP TABLE IF EXISTS post_reaction_counts;
+DROP INDEX IF EXISTS idx_post_reactions_user_id;
+DROP TABLE IF EXISTS post_reactions;
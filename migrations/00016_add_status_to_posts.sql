@@ -0,0 +1,12 @@
+-- +goose Up
+-- Lifecycle of posts, existing posts stay published
+ALTER TABLE posts ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN publish_at TIMESTAMP NULL;
+UPDATE posts SET publish_at = created_at;
+-- Used by the scheduler looking for due posts
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON posts(publish_at) WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_posts_scheduled;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Previous versions of posts, written along with every update
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id UUID PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    tags TEXT[],
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (post_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS post_revisions;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Uploaded files, the blob of identical files is stored once under the same key
+CREATE TABLE IF NOT EXISTS uploads (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    checksum CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, checksum)
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_checksum ON uploads(checksum);
+
+-- Uploads attached to posts, in display order
+CREATE TABLE IF NOT EXISTS post_attachments (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    upload_id UUID NOT NULL REFERENCES uploads(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, upload_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_attachments_upload_id ON post_attachments(upload_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_post_attachments_upload_id;
+DROP TABLE IF EXISTS post_attachments;
+DROP INDEX IF EXISTS idx_uploads_checksum;
+DROP TABLE IF EXISTS uploads;
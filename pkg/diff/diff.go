@@ -0,0 +1,157 @@
+// Package diff computes line based differences between two texts with the Myers algorithm.
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op tells what happened to a line
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// maxEdits bounds the time and memory spent on a diff. Texts with more changed lines
+// are reported as deleted and inserted as a whole, which is still a correct diff.
+const maxEdits = 1000
+
+// Line is a line of the diff, deleted lines come from the old text and inserted ones from the new text
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the line diff turning text a into text b
+func Lines(a, b string) []Line {
+	return Diff(split(a), split(b))
+}
+
+// Diff returns a shortest edit script turning a into b, interleaved with the unchanged lines
+func Diff(a, b []string) []Line {
+	// The common prefix and suffix never change, skipping them keeps the search small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	lines = appendLines(lines, Equal, a[:prefix])
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	lines = appendLines(lines, Equal, a[len(a)-suffix:])
+
+	return lines
+}
+
+// myers searches the shortest edit script, see "An O(ND) Difference Algorithm and Its Variations".
+// v holds the furthest x reached on every diagonal k = x - y, trace keeps v of every round
+// for the backtracking.
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replace(a, b)
+	}
+
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	trace := make([][]int, 0, limit)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+
+	return replace(a, b)
+}
+
+// backtrack walks the trace back from the end of both texts and returns the lines in order
+func backtrack(a, b []string, trace [][]int, edits int) []Line {
+	x, y := len(a), len(b)
+	lines := make([]Line, 0, len(a)+len(b))
+
+	for d := edits; d > 0; d-- {
+		// Diagonals of the previous round, stored from -(d-1) to d-1
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			lines = append(lines, Line{Op: Insert, Text: b[y-1]})
+		} else {
+			lines = append(lines, Line{Op: Delete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for ; x > 0; x-- {
+		lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+	}
+
+	slices.Reverse(lines)
+	return lines
+}
+
+func replace(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	lines = appendLines(lines, Delete, a)
+	return appendLines(lines, Insert, b)
+}
+
+func appendLines(lines []Line, op Op, texts []string) []Line {
+	for _, text := range texts {
+		lines = append(lines, Line{Op: op, Text: text})
+	}
+
+	return lines
+}
+
+// split breaks a text into lines, a trailing newline does not start another line
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rebuild applies the diff, returning the old and the new lines
+func rebuild(lines []Line) (a, b []string) {
+	for _, line := range lines {
+		if line.Op != Insert {
+			a = append(a, line.Text)
+		}
+		if line.Op != Delete {
+			b = append(b, line.Text)
+		}
+	}
+	return a, b
+}
+
+func edits(lines []Line) int {
+	n := 0
+	for _, line := range lines {
+		if line.Op != Equal {
+			n++
+		}
+	}
+	return n
+}
+
+// lcs returns the length of the longest common subsequence, the shortest edit script
+// deletes and inserts every other line
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"both empty", "", "", []Line{}},
+		{"identical", "a\nb\n", "a\nb\n", []Line{{Equal, "a"}, {Equal, "b"}}},
+		{"insert only", "", "a\nb", []Line{{Insert, "a"}, {Insert, "b"}}},
+		{"delete only", "a\nb", "", []Line{{Delete, "a"}, {Delete, "b"}}},
+		{"insert in the middle", "a\nc", "a\nb\nc", []Line{{Equal, "a"}, {Insert, "b"}, {Equal, "c"}}},
+		{"delete in the middle", "a\nb\nc", "a\nc", []Line{{Equal, "a"}, {Delete, "b"}, {Equal, "c"}}},
+		{"change a line", "a\nb\nc", "a\nx\nc", []Line{{Equal, "a"}, {Delete, "b"}, {Insert, "x"}, {Equal, "c"}}},
+		{"append a line", "a", "a\nb", []Line{{Equal, "a"}, {Insert, "b"}}},
+		// A trailing newline ends the last line, it does not start another one
+		{"trailing newline added", "a\nb", "a\nb\n", []Line{{Equal, "a"}, {Equal, "b"}}},
+		{"trailing newline removed", "a\n", "a", []Line{{Equal, "a"}}},
+		{"empty last line", "a\n", "a\n\n", []Line{{Equal, "a"}, {Insert, ""}}},
+		{"CRLF line endings", "a\r\nb\r\n", "a\nb\n", []Line{{Equal, "a"}, {Equal, "b"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !slices.Equal(got, tt.want) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"paper example", "a b c a b b a", "c b a b a c"},
+		{"reversed", "1 2 3 4 5", "5 4 3 2 1"},
+		{"nothing in common", "a b c", "x y z"},
+		{"repeated lines", "a a a b a a", "a b a a a a"},
+		{"shared prefix and suffix", "s t a r t x y e n d", "s t a r t z e n d"},
+		{"interleaved", "a x b y c z", "x a y b z c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			lines := Diff(a, b)
+
+			gotA, gotB := rebuild(lines)
+			if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+				t.Fatalf("diff rebuilds %v and %v, want %v and %v", gotA, gotB, a, b)
+			}
+
+			if got, want := edits(lines), len(a)+len(b)-2*lcs(a, b); got != want {
+				t.Errorf("%d edits, want the shortest script of %d", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffBeyondMaxEdits(t *testing.T) {
+	a := make([]string, maxEdits)
+	b := make([]string, maxEdits)
+	for i := range maxEdits {
+		a[i] = "a" + strings.Repeat("x", i)
+		b[i] = "b" + strings.Repeat("x", i)
+	}
+
+	lines := Diff(a, b)
+	gotA, gotB := rebuild(lines)
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+		t.Fatal("a diff over the edit limit doesn't rebuild the texts")
+	}
+}
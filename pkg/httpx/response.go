@@ -44,6 +44,15 @@ func Error(w http.ResponseWriter, msg string, status int) {
 	}, status)
 }
 
+// ErrorWithData writes an error message along with data, e.g. the current state of a resource
+func ErrorWithData(w http.ResponseWriter, msg string, data any, status int) {
+	respondJSON(w, APIResponse{
+		Success: false,
+		Data:    data,
+		Error:   msg,
+	}, status)
+}
+
 // Writes a list of multiple errors
 func Errors(w http.ResponseWriter, errs []string, status int) {
 	respondJSON(w, APIResponse{
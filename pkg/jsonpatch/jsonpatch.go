@@ -0,0 +1,440 @@
+// Package jsonpatch applies JSON Merge Patches (RFC 7396) and JSON Patches (RFC 6902) to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Media types of the patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrMalformed is returned if the document or the patch is not valid JSON of the expected shape
+	ErrMalformed = errors.New("jsonpatch: malformed patch")
+	// ErrInvalidPatch is returned if an operation cannot be applied, e.g. its path does not exist
+	ErrInvalidPatch = errors.New("jsonpatch: patch cannot be applied")
+	// ErrTestFailed is returned if a test operation does not match the document
+	ErrTestFailed = errors.New("jsonpatch: test operation failed")
+)
+
+// MergePatch applies a JSON Merge Patch to the document.
+// Members of the patch replace those of the document, null removes them.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+
+	return object
+}
+
+// operation is a single operation of a JSON Patch, members are kept raw to tell missing ones apart
+type operation map[string]json.RawMessage
+
+// Apply applies a JSON Patch to the document. The operations are applied in order and
+// the patch fails as a whole if one of them fails.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	for i, op := range ops {
+		target, err = op.apply(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w (operation %d)", err, i)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (op operation) apply(doc any) (any, error) {
+	name, err := op.str("op")
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := op.pointer("path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		return put(doc, path, value)
+	case "move":
+		from, err := op.pointer("from")
+		if err != nil {
+			return nil, err
+		}
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, err := op.pointer("from")
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, clone(value))
+	case "test":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, path)
+		if err != nil || !Equal(actual, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op '%s'", ErrMalformed, name)
+	}
+}
+
+func (op operation) str(member string) (string, error) {
+	raw, ok := op[member]
+	if !ok {
+		return "", fmt.Errorf("%w: missing '%s'", ErrMalformed, member)
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("%w: '%s' must be a string", ErrMalformed, member)
+	}
+
+	return s, nil
+}
+
+func (op operation) pointer(member string) ([]string, error) {
+	s, err := op.str(member)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePointer(s)
+}
+
+func (op operation) value() (any, error) {
+	raw, ok := op["value"]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing 'value'", ErrMalformed)
+	}
+
+	return decode(raw)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("%w: invalid pointer '%s'", ErrMalformed, s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// get returns the value the pointer refers to
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member '%s' not found", ErrInvalidPatch, token)
+			}
+			doc = value
+		case []any:
+			i, err := index(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: cannot traverse into '%s'", ErrInvalidPatch, token)
+		}
+	}
+
+	return doc, nil
+}
+
+// put sets the value the pointer refers to, the parent must exist
+func put(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+	case []any:
+		i, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	default:
+		return nil, fmt.Errorf("%w: cannot set '%s'", ErrInvalidPatch, token)
+	}
+
+	return doc, nil
+}
+
+// add sets an object member or inserts into an array, "-" appends to an array
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+		return doc, nil
+	case []any:
+		i := len(node)
+		if token != "-" {
+			if i, err = index(token, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		// Inserting may reallocate the array, so it is put back into its parent
+		return put(doc, parentPath, slices.Insert(node, i, value))
+	default:
+		return nil, fmt.Errorf("%w: cannot add to '%s'", ErrInvalidPatch, token)
+	}
+}
+
+// remove deletes an object member or an array element
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	parentPath, token := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[token]; !ok {
+			return nil, fmt.Errorf("%w: member '%s' not found", ErrInvalidPatch, token)
+		}
+		delete(node, token)
+		return doc, nil
+	case []any:
+		i, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, parentPath, slices.Delete(node, i, i+1))
+	default:
+		return nil, fmt.Errorf("%w: cannot remove '%s'", ErrInvalidPatch, token)
+	}
+}
+
+// index parses an array index, leading zeros are not allowed
+func index(token string, last int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: invalid array index '%s'", ErrInvalidPatch, token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > last {
+		return 0, fmt.Errorf("%w: array index '%s' out of range", ErrInvalidPatch, token)
+	}
+
+	return i, nil
+}
+
+// ChangedMembers returns the names of the top level members that differ between two
+// JSON objects, including added and removed ones, in sorted order
+func ChangedMembers(before, after []byte) ([]string, error) {
+	a, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := decode(after)
+	if err != nil {
+		return nil, err
+	}
+
+	objectA, okA := a.(map[string]any)
+	objectB, okB := b.(map[string]any)
+	if !okA || !okB {
+		return nil, fmt.Errorf("%w: documents must be objects", ErrMalformed)
+	}
+
+	changed := []string{}
+	for name, value := range objectA {
+		if other, ok := objectB[name]; !ok || !Equal(value, other) {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range objectB {
+		if _, ok := objectA[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// Equal reports whether two decoded JSON values are equal, numbers are compared by value
+func Equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, value := range x {
+			other, ok := y[name]
+			if !ok || !Equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		return ok && slices.EqualFunc(x, y, Equal)
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	default:
+		return a == b
+	}
+}
+
+// decode parses a JSON value, numbers are kept as json.Number so they are written back unchanged
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after the JSON value", ErrMalformed)
+	}
+
+	return value, nil
+}
+
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for name, member := range v {
+			object[name] = clone(member)
+		}
+		return object
+	case []any:
+		array := make([]any, len(v))
+		for i, element := range v {
+			array[i] = clone(element)
+		}
+		return array
+	default:
+		return v
+	}
+}
@@ -0,0 +1,197 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/goapi/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestClient(p *oidctest.Provider) *Client {
+	return NewClient(Config{
+		IssuerURL:   p.Issuer(),
+		ClientID:    p.ClientID,
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email"},
+	}, p.Server.Client())
+}
+
+func TestDiscover(t *testing.T) {
+	p := oidctest.NewProvider("goapi")
+	defer p.Close()
+
+	d, err := newTestClient(p).Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Issuer != p.Issuer() || d.TokenEndpoint != p.Issuer()+"/token" || d.JWKSURI != p.Issuer()+"/jwks" {
+		t.Errorf("discovery = %+v", d)
+	}
+}
+
+func TestDiscoverRejectsOtherIssuer(t *testing.T) {
+	p := oidctest.NewProvider("goapi")
+	defer p.Close()
+
+	c := newTestClient(p)
+	c.cfg.IssuerURL = p.Issuer() + "/"
+	if _, err := c.Discover(context.Background()); err == nil {
+		t.Fatal("discovery accepted an issuer that doesn't match the configured one")
+	}
+}
+
+func TestDiscoverRejectsIncompleteMetadata(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"issuer":"` + srv.URL + `","authorization_endpoint":"` + srv.URL + `/authorize"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Config{IssuerURL: srv.URL, ClientID: "goapi"}, srv.Client())
+	if _, err := c.Discover(context.Background()); err == nil {
+		t.Fatal("discovery accepted metadata without token endpoint and jwks")
+	}
+}
+
+// RFC 7636 Appendix B
+func TestCodeChallengeS256(t *testing.T) {
+	got := CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("CodeChallengeS256 = %q, want %q", got, want)
+	}
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	v, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 7636 4.1: 43 to 128 characters of the unreserved set
+	if len(v) < 43 || len(v) > 128 || strings.Trim(v, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~") != "" {
+		t.Errorf("invalid code verifier %q", v)
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p := oidctest.NewProvider("goapi")
+	defer p.Close()
+	c := newTestClient(p)
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := c.AuthCodeURL(ctx, "state", "nonce", CodeChallengeS256(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("code_challenge") != CodeChallengeS256(verifier) || q.Get("code_challenge_method") != "S256" ||
+		q.Get("state") != "state" || q.Get("nonce") != "nonce" || q.Get("redirect_uri") != "http://localhost/callback" {
+		t.Fatalf("unexpected authorization url %q", authURL)
+	}
+
+	identity := oidctest.Identity{Subject: "sub-1", Email: "alice@example.com", EmailVerified: true}
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		code, err := p.Authorize(authURL, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		other, _ := NewCodeVerifier()
+		if _, err := c.Exchange(ctx, code, other); err == nil {
+			t.Fatal("exchange succeeded with a verifier that doesn't match the challenge")
+		}
+	})
+
+	t.Run("matching verifier", func(t *testing.T) {
+		code, err := p.Authorize(authURL, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens, err := c.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		idToken, err := c.VerifyIDToken(ctx, tokens.IDToken, "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idToken.Subject != "sub-1" || idToken.Email != "alice@example.com" || !idToken.EmailVerified {
+			t.Errorf("id token = %+v", idToken)
+		}
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := oidctest.NewProvider("goapi")
+	defer p.Close()
+	c := newTestClient(p)
+
+	identity := oidctest.Identity{Subject: "sub-1", Email: "alice@example.com"}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with returns valid claims with one of them changed
+	with := func(name string, value any) jwt.MapClaims {
+		claims := p.Claims(identity, "nonce")
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid", p.IDToken(p.Claims(identity, "nonce")), true},
+		{"other issuer", p.IDToken(with("iss", "https://evil.example.com")), false},
+		{"other audience", p.IDToken(with("aud", "someone-else")), false},
+		{"other nonce", p.IDToken(with("nonce", "replayed")), false},
+		{"expired", p.IDToken(with("exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"no expiry", p.IDToken(with("exp", nil)), false},
+		{"no subject", p.IDToken(with("sub", nil)), false},
+		{"other signature", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, p.Claims(identity, "nonce"))
+			token.Header["kid"] = "test-key"
+			signed, _ := token.SignedString(otherKey)
+			return signed
+		}(), false},
+		{"unsigned", func() string {
+			signed, _ := jwt.NewWithClaims(jwt.SigningMethodNone, p.Claims(identity, "nonce")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.VerifyIDToken(context.Background(), tt.token, "nonce")
+			if (err == nil) != tt.valid {
+				t.Errorf("VerifyIDToken() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// secret of the RFC 6238 test vectors, the ASCII string "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238, Appendix B, SHA1. The RFC lists 8 digit codes, these are their last 6 digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.code {
+				t.Errorf("Code() = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestCodeAcceptsLowercaseAndPadding(t *testing.T) {
+	for _, secret := range []string{"gezdgnbvgy3tqojqgezdgnbvgy3tqojq", rfcSecret + "===="} {
+		if got, err := Code(secret, 1); err != nil || got != "287082" {
+			t.Errorf("Code(%q) = %s, %v, want 287082", secret, got, err)
+		}
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code() accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		code string
+		skew int
+		step int64
+		ok   bool
+	}{
+		{"current step", code(current), 1, current, true},
+		{"previous step", code(current - 1), 1, current - 1, true},
+		{"next step", code(current + 1), 1, current + 1, true},
+		{"beyond the window in the past", code(current - 2), 1, 0, false},
+		{"beyond the window in the future", code(current + 2), 1, 0, false},
+		{"wider window", code(current - 2), 2, current - 2, true},
+		{"no skew", code(current - 1), 0, 0, false},
+		{"surrounding spaces", " " + code(current) + "\n", 1, current, true},
+		{"too short", code(current)[:5], 1, 0, false},
+		{"too long", code(current) + "0", 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.ok || step != tt.step {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Go API", "jane@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Go API:jane@example.com" {
+		t.Errorf("URI() = %s", u)
+	}
+
+	q := u.Query()
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Go API" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("URI() parameters = %v", q)
+	}
+}